@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const (
+	eventChirpCreated        = "chirp.created"
+	eventChirpDeleted        = "chirp.deleted"
+	eventNotificationCreated = "notification.created"
+	// eventRelationsChanged is internal and never sent to clients. It tells
+	// UserId's subscriptions to reload who they follow, block and mute.
+	eventRelationsChanged = "relations.changed"
+)
+
+const (
+	eventHistorySize      = 256
+	subscriberBufferSize  = 64
+	postgresEventsChannel = "chirpy_events"
+	eventPublishTimeout   = 5 * time.Second
+)
+
+// event is a change pushed to realtime clients. UserId is the chirp's author
+// for chirp events and the recipient for notifications; the other fields let
+// subscribers route and filter events without decoding Data, the JSON
+// payload that's encoded once and shared by every subscriber. RefUserId is
+// the author of the chirp a rechirp or quote refers to, and KeywordMuterIds
+// are the users with a muted keyword the chirp matches.
+type event struct {
+	Id              int64
+	Kind            string
+	UserId          uuid.UUID
+	ChirpId         uuid.UUID
+	ParentId        uuid.UUID
+	NotificationId  uuid.UUID
+	RefUserId       uuid.UUID
+	KeywordMuterIds map[uuid.UUID]struct{}
+	Data            json.RawMessage
+}
+
+// eventMessage is an event as it's sent through Postgres NOTIFY, which
+// rejects payloads of 8000 bytes or more. It only identifies the event, and
+// every instance loads the rest when it comes back through LISTEN. Id is the
+// notification for notification events and the chirp otherwise.
+type eventMessage struct {
+	Kind     string    `json:"kind"`
+	Id       uuid.UUID `json:"id"`
+	UserId   uuid.UUID `json:"user_id"`
+	ParentId uuid.UUID `json:"parent_id"`
+}
+
+// eventLoader rebuilds an event, and the payload it's published with, from
+// the message sent through NOTIFY. ok is false when there's nothing left to
+// send, e.g. the chirp was deleted in the meantime.
+type eventLoader func(ctx context.Context, m eventMessage) (e event, payload interface{}, ok bool, err error)
+
+// broker fans events out to realtime subscribers and keeps a short history
+// so clients that reconnect can resume where they left off.
+//
+// With Postgres pub/sub enabled, events are published through NOTIFY and
+// every instance, including the one that published, broadcasts them when
+// they come back through LISTEN. Event IDs are assigned by each instance, so
+// resuming only works against the instance the client was connected to.
+type broker struct {
+	db          *database.Queries
+	usePostgres bool
+
+	mu          sync.Mutex
+	nextId      int64
+	history     []event
+	subscribers map[chan event]struct{}
+	closed      bool
+}
+
+func newBroker(db *database.Queries, usePostgres bool) *broker {
+	return &broker{
+		db:          db,
+		usePostgres: usePostgres,
+		subscribers: make(map[chan event]struct{}),
+	}
+}
+
+// publish sends e to every subscriber with payload as its data. It runs on
+// its own context, so it isn't cut short by the request that caused it, and
+// failures are logged, since a missed realtime event shouldn't fail that
+// request.
+func (b *broker) publish(e event, payload interface{}) {
+	if !b.usePostgres {
+		b.send(e, payload)
+		return
+	}
+
+	m := eventMessage{
+		Kind:     e.Kind,
+		Id:       e.ChirpId,
+		UserId:   e.UserId,
+		ParentId: e.ParentId,
+	}
+
+	if e.Kind == eventNotificationCreated {
+		m.Id = e.NotificationId
+	}
+
+	message, err := json.Marshal(m)
+	if err != nil {
+		log.Printf("Error marshalling %s event: %v", e.Kind, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), eventPublishTimeout)
+	defer cancel()
+
+	err = b.db.NotifyEvent(ctx, database.NotifyEventParams{
+		Channel: postgresEventsChannel,
+		Payload: string(message),
+	})
+
+	if err != nil {
+		log.Printf("Error publishing %s event: %v", e.Kind, err)
+	}
+}
+
+// send encodes payload as e's data and broadcasts it.
+func (b *broker) send(e event, payload interface{}) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error marshalling %s event: %v", e.Kind, err)
+		return
+	}
+	e.Data = data
+
+	b.broadcast(e)
+}
+
+func (b *broker) broadcast(e event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+
+	b.nextId++
+	e.Id = b.nextId
+
+	b.history = append(b.history, e)
+	if len(b.history) > eventHistorySize {
+		b.history = b.history[len(b.history)-eventHistorySize:]
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			// The subscriber isn't keeping up. Dropping it lets the client
+			// reconnect and resume from the history instead of stalling
+			// everyone else.
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers a new subscriber. Events after lastEventId that are
+// still in the history are returned so the caller can replay them first.
+// The channel is closed when the subscriber falls behind or the broker
+// shuts down.
+func (b *broker) subscribe(lastEventId int64) (<-chan event, []event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan event, subscriberBufferSize)
+	if b.closed {
+		close(ch)
+		return ch, nil, func() {}
+	}
+
+	b.subscribers[ch] = struct{}{}
+
+	var missed []event
+	if lastEventId > 0 {
+		for _, e := range b.history {
+			if e.Id > lastEventId {
+				missed = append(missed, e)
+			}
+		}
+	}
+
+	unsubscribe := func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return ch, missed, unsubscribe
+}
+
+// close disconnects every subscriber so long-lived streams end and the
+// server can shut down.
+func (b *broker) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for ch := range b.subscribers {
+		delete(b.subscribers, ch)
+		close(ch)
+	}
+}
+
+func (b *broker) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.closed
+}
+
+// listen relays events published by any instance through Postgres NOTIFY to
+// this instance's subscribers, loading each one with load, until ctx is
+// cancelled.
+func (b *broker) listen(ctx context.Context, dbURL string, load eventLoader) error {
+	listener := pq.NewListener(dbURL, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("Postgres listener error: %v", err)
+		}
+	})
+	defer listener.Close()
+
+	err := listener.Listen(postgresEventsChannel)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notification := <-listener.Notify:
+			// A nil notification means the connection was re-established
+			// and anything sent in between was lost.
+			if notification == nil {
+				continue
+			}
+
+			var m eventMessage
+			err := json.Unmarshal([]byte(notification.Extra), &m)
+			if err != nil {
+				log.Printf("Error decoding event: %v", err)
+				continue
+			}
+
+			loadCtx, cancel := context.WithTimeout(ctx, eventPublishTimeout)
+			e, payload, ok, err := load(loadCtx, m)
+			cancel()
+
+			if err != nil {
+				log.Printf("Error loading %s event: %v", m.Kind, err)
+				continue
+			}
+
+			if ok {
+				b.send(e, payload)
+			}
+		case <-time.After(90 * time.Second):
+			go listener.Ping()
+		}
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// mention marks an @username in the body. Start and End are offsets in
+// Unicode code points, with End exclusive.
+type mention struct {
+	UserId   uuid.UUID `json:"user_id"`
+	Username string    `json:"username"`
+	Start    int       `json:"start"`
+	End      int       `json:"end"`
+}
+
+// attachment is an image attached to a chirp.
+type attachment struct {
+	Id           uuid.UUID `json:"id"`
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	ContentType  string    `json:"content_type"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Size         int       `json:"size"`
+}
+
+func (cfg *apiConfig) attachmentFromDB(attachmentDB database.Attachment) attachment {
+	return attachment{
+		Id:           attachmentDB.ID,
+		URL:          cfg.storage.URL(attachmentDB.StorageKey),
+		ThumbnailURL: cfg.storage.URL(attachmentDB.ThumbnailKey),
+		ContentType:  attachmentDB.ContentType,
+		Width:        int(attachmentDB.Width),
+		Height:       int(attachmentDB.Height),
+		Size:         int(attachmentDB.SizeBytes),
+	}
+}
+
+type chirp struct {
+	Id          uuid.UUID    `json:"id"`
+	UserId      uuid.UUID    `json:"user_id"`
+	Body        string       `json:"body"`
+	ParentId    *uuid.UUID   `json:"parent_id,omitempty"`
+	RechirpOf   *chirp       `json:"rechirp_of,omitempty"`
+	QuoteOf     *chirp       `json:"quote_of,omitempty"`
+	Mentions    []mention    `json:"mentions"`
+	Attachments []attachment `json:"attachments"`
+	Poll        *poll        `json:"poll,omitempty"`
+	ReplyCount  int          `json:"reply_count"`
+	LikeCount   int          `json:"like_count"`
+	LikedByMe   bool         `json:"liked_by_me"`
+	Bookmarked  bool         `json:"bookmarked"`
+	Pinned      bool         `json:"pinned,omitempty"`
+	Edited      bool         `json:"edited"`
+	Deleted     bool         `json:"deleted,omitempty"`
+	Hidden      bool         `json:"hidden,omitempty"`
+	Unavailable bool         `json:"unavailable,omitempty"`
+	Snippet     string       `json:"snippet,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+func chirpFromDB(chirpDB database.Chirp) chirp {
+	c := chirp{
+		Id:          chirpDB.ID,
+		UserId:      chirpDB.UserID,
+		Body:        chirpDB.Body,
+		Mentions:    []mention{},
+		Attachments: []attachment{},
+		Edited:      chirpDB.EditedAt.Valid,
+		Deleted:     chirpDB.IsTombstone || chirpDB.DeletedAt.Valid,
+		Hidden:      chirpDB.HiddenAt.Valid,
+		CreatedAt:   chirpDB.CreatedAt,
+		UpdatedAt:   chirpDB.UpdatedAt,
+	}
+
+	if chirpDB.ParentID.Valid {
+		parentId := chirpDB.ParentID.UUID
+		c.ParentId = &parentId
+	}
+
+	// Deleted chirps only show up as placeholders, e.g. in threads, and keep
+	// their body hidden while it can still be restored.
+	if chirpDB.DeletedAt.Valid {
+		c.Body = ""
+	}
+
+	return c
+}
+
+// buildChirps turns database rows into the chirp JSON shape as seen by
+// viewerId, which is uuid.Nil for anonymous requests. Rechirped and quoted
+// chirps are embedded one level deep. Everything is loaded with one query
+// per kind of data for the whole slice rather than one per chirp.
+func (cfg *apiConfig) buildChirps(ctx context.Context, viewerId uuid.UUID, chirpsDB []database.Chirp) ([]chirp, error) {
+	return cfg.assembleChirps(ctx, viewerId, false, chirpsDB)
+}
+
+// buildChirpsForReview is buildChirps for moderators, who see the text of
+// hidden chirps so they can review them again. It must only be used behind
+// requireRole.
+func (cfg *apiConfig) buildChirpsForReview(ctx context.Context, chirpsDB []database.Chirp) ([]chirp, error) {
+	return cfg.assembleChirps(ctx, uuid.Nil, true, chirpsDB)
+}
+
+func (cfg *apiConfig) assembleChirps(ctx context.Context, viewerId uuid.UUID, revealHidden bool, chirpsDB []database.Chirp) ([]chirp, error) {
+	chirps := make([]chirp, 0, len(chirpsDB))
+	if len(chirpsDB) == 0 {
+		return chirps, nil
+	}
+
+	var refIds []uuid.UUID
+	for _, chirpDB := range chirpsDB {
+		chirps = append(chirps, chirpFromDB(chirpDB))
+
+		if chirpDB.RechirpOfID.Valid {
+			refIds = append(refIds, chirpDB.RechirpOfID.UUID)
+		}
+		if chirpDB.QuoteOfID.Valid {
+			refIds = append(refIds, chirpDB.QuoteOfID.UUID)
+		}
+	}
+
+	var refs []chirp
+	if len(refIds) > 0 {
+		refsDB, err := cfg.db.GetChirpsByIds(ctx, database.GetChirpsByIdsParams{
+			ChirpIds: refIds,
+			ViewerID: viewerId,
+		})
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, refDB := range refsDB {
+			refs = append(refs, chirpFromDB(refDB))
+		}
+	}
+
+	all := make([]*chirp, 0, len(chirps)+len(refs))
+	for i := range chirps {
+		all = append(all, &chirps[i])
+	}
+	for i := range refs {
+		all = append(all, &refs[i])
+	}
+
+	err := cfg.loadChirpDetails(ctx, viewerId, revealHidden, all)
+	if err != nil {
+		return nil, err
+	}
+
+	refsById := make(map[uuid.UUID]*chirp, len(refs))
+	for i := range refs {
+		refsById[refs[i].Id] = &refs[i]
+	}
+
+	// Chirps the viewer can't see, because their author blocked them, are
+	// still embedded as placeholders so clients can tell what happened.
+	ref := func(id uuid.UUID) *chirp {
+		if c, ok := refsById[id]; ok {
+			return c
+		}
+
+		return &chirp{
+			Id:          id,
+			Mentions:    []mention{},
+			Attachments: []attachment{},
+			Unavailable: true,
+		}
+	}
+
+	for i, chirpDB := range chirpsDB {
+		if chirpDB.RechirpOfID.Valid {
+			chirps[i].RechirpOf = ref(chirpDB.RechirpOfID.UUID)
+		}
+		if chirpDB.QuoteOfID.Valid {
+			chirps[i].QuoteOf = ref(chirpDB.QuoteOfID.UUID)
+		}
+	}
+
+	return chirps, nil
+}
+
+func (cfg *apiConfig) buildChirp(ctx context.Context, viewerId uuid.UUID, chirpDB database.Chirp) (chirp, error) {
+	chirps, err := cfg.buildChirps(ctx, viewerId, []database.Chirp{chirpDB})
+	if err != nil {
+		return chirp{}, err
+	}
+
+	return chirps[0], nil
+}
+
+// loadChirpDetails fills in the per-chirp counts, mentions, attachments and
+// polls of chirps that aren't deleted, and blanks chirps hidden by moderators
+// unless viewerId wrote them or revealHidden is set. The same chirp may
+// appear more than once, e.g. when it's both in the page and quoted by
+// another.
+func (cfg *apiConfig) loadChirpDetails(ctx context.Context, viewerId uuid.UUID, revealHidden bool, chirps []*chirp) error {
+	chirpIds := make([]uuid.UUID, 0, len(chirps))
+	byId := make(map[uuid.UUID][]*chirp, len(chirps))
+	for _, c := range chirps {
+		if c.Deleted {
+			continue
+		}
+
+		// Others can still come across a hidden chirp, e.g. when it's quoted,
+		// but only as a placeholder.
+		if c.Hidden && c.UserId != viewerId && !revealHidden {
+			c.Body = ""
+			continue
+		}
+
+		if _, ok := byId[c.Id]; !ok {
+			chirpIds = append(chirpIds, c.Id)
+		}
+		byId[c.Id] = append(byId[c.Id], c)
+	}
+
+	replyCounts, err := cfg.db.GetReplyCounts(ctx, chirpIds)
+	if err != nil {
+		return err
+	}
+
+	for _, replyCount := range replyCounts {
+		for _, c := range byId[replyCount.ChirpID] {
+			c.ReplyCount = int(replyCount.ReplyCount)
+		}
+	}
+
+	likeStats, err := cfg.db.GetLikeStats(ctx, database.GetLikeStatsParams{
+		ViewerID: viewerId,
+		ChirpIds: chirpIds,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	for _, likeStat := range likeStats {
+		for _, c := range byId[likeStat.ChirpID] {
+			c.LikeCount = int(likeStat.LikeCount)
+			c.LikedByMe = likeStat.LikedByMe
+		}
+	}
+
+	if viewerId != uuid.Nil {
+		bookmarkedIds, err := cfg.db.GetBookmarkedChirpIds(ctx, database.GetBookmarkedChirpIdsParams{
+			UserID:   viewerId,
+			ChirpIds: chirpIds,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for _, bookmarkedId := range bookmarkedIds {
+			for _, c := range byId[bookmarkedId] {
+				c.Bookmarked = true
+			}
+		}
+	}
+
+	mentionsDB, err := cfg.db.GetMentions(ctx, chirpIds)
+	if err != nil {
+		return err
+	}
+
+	for _, mentionDB := range mentionsDB {
+		for _, c := range byId[mentionDB.ChirpID] {
+			c.Mentions = append(c.Mentions, mention{
+				UserId:   mentionDB.UserID,
+				Username: mentionDB.Username,
+				Start:    int(mentionDB.StartIndex),
+				End:      int(mentionDB.EndIndex),
+			})
+		}
+	}
+
+	attachmentsDB, err := cfg.db.GetAttachments(ctx, chirpIds)
+	if err != nil {
+		return err
+	}
+
+	for _, attachmentDB := range attachmentsDB {
+		for _, c := range byId[attachmentDB.ChirpID.UUID] {
+			c.Attachments = append(c.Attachments, cfg.attachmentFromDB(attachmentDB))
+		}
+	}
+
+	polls, err := cfg.buildPolls(ctx, viewerId, chirpIds)
+	if err != nil {
+		return err
+	}
+
+	for chirpId, p := range polls {
+		for _, c := range byId[chirpId] {
+			c.Poll = p
+		}
+	}
+
+	return nil
+}
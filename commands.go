@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// runCommand runs a one-off admin command given on the command line instead
+// of starting the server.
+func runCommand(ctx context.Context, db *database.Queries, args []string) error {
+	switch args[0] {
+	case "bootstrap-admin":
+		if len(args) != 2 {
+			return errors.New("usage: chirpy bootstrap-admin <email>")
+		}
+		return bootstrapAdmin(ctx, db, args[1])
+	default:
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// bootstrapAdmin makes the user with email the first admin. Once there is an
+// admin, further admins are appointed through the API instead.
+func bootstrapAdmin(ctx context.Context, db *database.Queries, email string) error {
+	updated, err := db.BootstrapAdmin(ctx, email)
+	if err != nil {
+		return fmt.Errorf("couldn't promote user: %w", err)
+	}
+
+	if updated == 0 {
+		admins, err := db.CountAdmins(ctx)
+		if err != nil {
+			return fmt.Errorf("couldn't count admins: %w", err)
+		}
+
+		if admins > 0 {
+			return errors.New("an admin already exists; use PUT /admin/users/{userId}/role instead")
+		}
+
+		return fmt.Errorf("couldn't find user with email %s", email)
+	}
+
+	log.Printf("%s is now an admin", email)
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const schedulerInterval = 15 * time.Second
+
+// draft is a chirp that hasn't been published yet. Drafts with a publish time
+// are published by the scheduler once it passes.
+type draft struct {
+	Id            uuid.UUID   `json:"id"`
+	UserId        uuid.UUID   `json:"user_id"`
+	Body          string      `json:"body"`
+	ParentId      *uuid.UUID  `json:"parent_id,omitempty"`
+	QuoteOf       *uuid.UUID  `json:"quote_of,omitempty"`
+	AttachmentIds []uuid.UUID `json:"attachment_ids"`
+	PublishAt     *time.Time  `json:"publish_at"`
+	CreatedAt     time.Time   `json:"created_at"`
+	UpdatedAt     time.Time   `json:"updated_at"`
+}
+
+func draftFromDB(draftDB database.Draft) draft {
+	d := draft{
+		Id:            draftDB.ID,
+		UserId:        draftDB.UserID,
+		Body:          draftDB.Body,
+		AttachmentIds: draftDB.AttachmentIds,
+		CreatedAt:     draftDB.CreatedAt,
+		UpdatedAt:     draftDB.UpdatedAt,
+	}
+
+	if d.AttachmentIds == nil {
+		d.AttachmentIds = []uuid.UUID{}
+	}
+
+	if draftDB.ParentID.Valid {
+		parentId := draftDB.ParentID.UUID
+		d.ParentId = &parentId
+	}
+
+	if draftDB.QuoteOfID.Valid {
+		quoteOf := draftDB.QuoteOfID.UUID
+		d.QuoteOf = &quoteOf
+	}
+
+	if draftDB.PublishAt.Valid {
+		publishAt := draftDB.PublishAt.Time
+		d.PublishAt = &publishAt
+	}
+
+	return d
+}
+
+// publishDraft turns the draft returned by claim into a chirp. claim must
+// lock the draft's row, which is deleted in the same transaction that
+// creates the chirp, so a draft is published exactly once even when several
+// instances go for it at the same time. If the draft is no longer valid, e.g.
+// because the chirp it replies to was deleted, the *chirpInputError from
+// prepareChirp is returned and the draft is left as it was.
+func (cfg *apiConfig) publishDraft(ctx context.Context, claim func(q *database.Queries) (database.Draft, error)) (database.Draft, chirp, error) {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return database.Draft{}, chirp{}, err
+	}
+	defer tx.Rollback()
+
+	q := cfg.db.WithTx(tx)
+
+	draftDB, err := claim(q)
+	if err != nil {
+		return database.Draft{}, chirp{}, err
+	}
+
+	nc, err := cfg.prepareChirp(ctx, draftDB.UserID, draftDB.Body, draftDB.ParentID, draftDB.QuoteOfID, draftDB.AttachmentIds)
+	if err != nil {
+		return draftDB, chirp{}, err
+	}
+
+	chirpCreated, mentioned, err := cfg.saveChirp(ctx, q, nc)
+	if err != nil {
+		return draftDB, chirp{}, err
+	}
+
+	_, err = q.DeleteDraft(ctx, draftDB.ID)
+	if err != nil {
+		return draftDB, chirp{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return draftDB, chirp{}, err
+	}
+
+	c, err := cfg.finishChirp(ctx, chirpCreated, nc, mentioned)
+	return draftDB, c, err
+}
+
+// runScheduler publishes scheduled drafts as they fall due until ctx is
+// cancelled. It's safe to run on every instance.
+func (cfg *apiConfig) runScheduler(ctx context.Context) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.publishDueDrafts(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// publishDueDrafts publishes due drafts one at a time until there are none
+// left. Drafts that can't be published any more are unscheduled so they stay
+// with the user as plain drafts.
+func (cfg *apiConfig) publishDueDrafts(ctx context.Context) {
+	for ctx.Err() == nil {
+		// Each draft gets its own context so shutting down doesn't interrupt
+		// one that's half published.
+		draftCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+
+		draftDB, _, err := cfg.publishDraft(draftCtx, func(q *database.Queries) (database.Draft, error) {
+			return q.ClaimDueDraft(draftCtx)
+		})
+
+		var inputErr *chirpInputError
+		if errors.As(err, &inputErr) {
+			log.Printf("Unscheduling draft %s: %s", draftDB.ID, inputErr.message)
+			err = cfg.db.UnscheduleDraft(draftCtx, draftDB.ID)
+		}
+
+		cancel()
+
+		if errors.Is(err, sql.ErrNoRows) {
+			return
+		}
+
+		if err != nil {
+			log.Printf("Error publishing scheduled draft: %v", err)
+			return
+		}
+	}
+}
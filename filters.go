@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+	"github.com/nurmuh-alhakim18/chirpy/internal/filter"
+)
+
+const (
+	maxMutedKeywords      = 100
+	maxMutedKeywordLength = 50
+)
+
+// filterRule is a word or phrase managed through the admin API that chirps
+// are checked against when they're written.
+type filterRule struct {
+	Id        uuid.UUID     `json:"id"`
+	Phrase    string        `json:"phrase"`
+	Action    filter.Action `json:"action"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
+
+func filterRuleFromDB(ruleDB database.FilterRule) filterRule {
+	return filterRule{
+		Id:        ruleDB.ID,
+		Phrase:    ruleDB.Phrase,
+		Action:    filter.Action(ruleDB.Action),
+		CreatedAt: ruleDB.CreatedAt,
+		UpdatedAt: ruleDB.UpdatedAt,
+	}
+}
+
+// mutedKeyword hides chirps containing it from the user who muted it.
+// Keywords are matched in SQL when chirps are read, the same way as filter
+// rules: whole words, ignoring case and punctuation.
+type mutedKeyword struct {
+	Keyword   string    `json:"keyword"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// filterChirp checks body against the filter rules and returns it with
+// masked words replaced. A body matching a reject rule is returned as a
+// *chirpInputError.
+func (cfg *apiConfig) filterChirp(ctx context.Context, body string) (filter.Result, error) {
+	rulesDB, err := cfg.db.GetFilterRules(ctx)
+	if err != nil {
+		return filter.Result{}, err
+	}
+
+	rules := make([]filter.Rule, 0, len(rulesDB))
+	for _, ruleDB := range rulesDB {
+		rules = append(rules, filter.Rule{
+			Phrase: ruleDB.Phrase,
+			Action: filter.Action(ruleDB.Action),
+		})
+	}
+
+	result := filter.New(rules).Apply(body)
+	if result.Rejected {
+		return filter.Result{}, &chirpInputError{status: 400, message: "Chirp contains words that aren't allowed"}
+	}
+
+	return result, nil
+}
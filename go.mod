@@ -10,3 +10,11 @@ require (
 	github.com/lib/pq v1.10.9
 	golang.org/x/crypto v0.27.0
 )
+
+require github.com/gorilla/websocket v1.5.3
+
+require (
+	github.com/rivo/uniseg v0.4.7
+	golang.org/x/image v0.23.0
+	golang.org/x/text v0.21.0
+)
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+type blockedUser struct {
+	publicUser
+	BlockedAt time.Time `json:"blocked_at"`
+}
+
+type mutedUser struct {
+	publicUser
+	MutedAt time.Time `json:"muted_at"`
+}
+
+// authTargetUser reads the user in the request path and the authenticated
+// user, responding with an error and returning false if either is missing or
+// they're the same user.
+func (cfg *apiConfig) authTargetUser(w http.ResponseWriter, r *http.Request, action string) (userId, targetId uuid.UUID, ok bool) {
+	targetId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respError(w, 400, "Invalid user ID", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userId = auth.UserIdFromContext(r.Context())
+
+	if targetId == userId {
+		respError(w, 400, "Couldn't "+action+" yourself", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	_, err = cfg.db.GetUserById(r.Context(), targetId)
+	if err != nil {
+		respError(w, 404, "Couldn't find user", err)
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return userId, targetId, true
+}
+
+// --- BLOCK USER ---
+func (cfg *apiConfig) handlerBlockUser(w http.ResponseWriter, r *http.Request) {
+	userId, blockedId, ok := cfg.authTargetUser(w, r, "block")
+	if !ok {
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respError(w, 500, "Couldn't block user", err)
+		return
+	}
+	defer tx.Rollback()
+
+	q := cfg.db.WithTx(tx)
+
+	_, err = q.CreateBlock(r.Context(), database.CreateBlockParams{
+		BlockerID: userId,
+		BlockedID: blockedId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't block user", err)
+		return
+	}
+
+	// Blocking ends following in both directions, and CreateFollow won't
+	// let either start again until the block is lifted.
+	err = q.DeleteFollowsBetween(r.Context(), database.DeleteFollowsBetweenParams{
+		UserID:  userId,
+		OtherID: blockedId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't block user", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respError(w, 500, "Couldn't block user", err)
+		return
+	}
+
+	cfg.relationsChanged(userId, blockedId)
+
+	w.WriteHeader(204)
+}
+
+// --- UNBLOCK USER ---
+func (cfg *apiConfig) handlerUnblockUser(w http.ResponseWriter, r *http.Request) {
+	userId, blockedId, ok := cfg.authTargetUser(w, r, "unblock")
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteBlock(r.Context(), database.DeleteBlockParams{
+		BlockerID: userId,
+		BlockedID: blockedId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't unblock user", err)
+		return
+	}
+
+	cfg.relationsChanged(userId, blockedId)
+
+	w.WriteHeader(204)
+}
+
+// --- MUTE USER ---
+func (cfg *apiConfig) handlerMuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, mutedId, ok := cfg.authTargetUser(w, r, "mute")
+	if !ok {
+		return
+	}
+
+	err := cfg.db.CreateMute(r.Context(), database.CreateMuteParams{
+		MuterID: userId,
+		MutedID: mutedId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't mute user", err)
+		return
+	}
+
+	cfg.relationsChanged(userId)
+
+	w.WriteHeader(204)
+}
+
+// --- UNMUTE USER ---
+func (cfg *apiConfig) handlerUnmuteUser(w http.ResponseWriter, r *http.Request) {
+	userId, mutedId, ok := cfg.authTargetUser(w, r, "unmute")
+	if !ok {
+		return
+	}
+
+	err := cfg.db.DeleteMute(r.Context(), database.DeleteMuteParams{
+		MuterID: userId,
+		MutedID: mutedId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't unmute user", err)
+		return
+	}
+
+	cfg.relationsChanged(userId)
+
+	w.WriteHeader(204)
+}
+
+// --- GET BLOCKED USERS ---
+func (cfg *apiConfig) handlerGetBlockedUsers(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []blockedUser `json:"users"`
+		NextCursor string        `json:"next_cursor,omitempty"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	blockedDB, err := cfg.db.GetBlockedUsers(r.Context(), database.GetBlockedUsersParams{
+		UserID:          userId,
+		CursorBlockedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get blocked users", err)
+		return
+	}
+
+	var nextCursor string
+	if len(blockedDB) > p.limit {
+		blockedDB = blockedDB[:p.limit]
+		last := blockedDB[len(blockedDB)-1]
+		nextCursor = encodeCursor(last.BlockedAt, last.ID)
+	}
+
+	users := make([]blockedUser, 0, len(blockedDB))
+	for _, userDB := range blockedDB {
+		users = append(users, blockedUser{
+			publicUser: publicUser{
+				Id:          userDB.ID,
+				Username:    userDB.Username.String,
+				DisplayName: userDB.DisplayName,
+				AvatarURL:   cfg.avatarKeyURL(userDB.AvatarKey),
+				IsChirpyRed: userDB.IsChirpyRed,
+				CreatedAt:   userDB.CreatedAt,
+			},
+			BlockedAt: userDB.BlockedAt,
+		})
+	}
+
+	respJSON(w, 200, response{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
+
+// --- GET MUTED USERS ---
+func (cfg *apiConfig) handlerGetMutedUsers(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []mutedUser `json:"users"`
+		NextCursor string      `json:"next_cursor,omitempty"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	mutedDB, err := cfg.db.GetMutedUsers(r.Context(), database.GetMutedUsersParams{
+		UserID:        userId,
+		CursorMutedAt: p.cursorCreatedAt,
+		CursorID:      p.cursorId,
+		Limit:         p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get muted users", err)
+		return
+	}
+
+	var nextCursor string
+	if len(mutedDB) > p.limit {
+		mutedDB = mutedDB[:p.limit]
+		last := mutedDB[len(mutedDB)-1]
+		nextCursor = encodeCursor(last.MutedAt, last.ID)
+	}
+
+	users := make([]mutedUser, 0, len(mutedDB))
+	for _, userDB := range mutedDB {
+		users = append(users, mutedUser{
+			publicUser: publicUser{
+				Id:          userDB.ID,
+				Username:    userDB.Username.String,
+				DisplayName: userDB.DisplayName,
+				AvatarURL:   cfg.avatarKeyURL(userDB.AvatarKey),
+				IsChirpyRed: userDB.IsChirpyRed,
+				CreatedAt:   userDB.CreatedAt,
+			},
+			MutedAt: userDB.MutedAt,
+		})
+	}
+
+	respJSON(w, 200, response{
+		Users:      users,
+		NextCursor: nextCursor,
+	})
+}
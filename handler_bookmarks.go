@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// --- BOOKMARK CHIRP ---
+func (cfg *apiConfig) handlerBookmarkChirp(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	chirpDB, err := cfg.db.GetVisibleChirpById(r.Context(), database.GetVisibleChirpByIdParams{
+		ChirpID:  chirpId,
+		ViewerID: userId,
+	})
+
+	if err != nil || chirpDB.IsTombstone {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	err = cfg.db.CreateBookmark(r.Context(), database.CreateBookmarkParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't bookmark chirp", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- REMOVE BOOKMARK ---
+func (cfg *apiConfig) handlerRemoveBookmark(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	err = cfg.db.DeleteBookmark(r.Context(), database.DeleteBookmarkParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't remove bookmark", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- GET BOOKMARKS ---
+func (cfg *apiConfig) handlerGetBookmarks(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	// Bookmarks are listed newest first by when they were made, not when the
+	// chirp was.
+	bookmarksDB, err := cfg.db.GetBookmarks(r.Context(), database.GetBookmarksParams{
+		UserID:             userId,
+		CursorBookmarkedAt: p.cursorCreatedAt,
+		CursorID:           p.cursorId,
+		Limit:              p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get bookmarks", err)
+		return
+	}
+
+	var nextCursor string
+	if len(bookmarksDB) > p.limit {
+		bookmarksDB = bookmarksDB[:p.limit]
+		last := bookmarksDB[len(bookmarksDB)-1]
+		nextCursor = encodeCursor(last.BookmarkedAt, last.Chirp.ID)
+	}
+
+	chirpsDB := make([]database.Chirp, 0, len(bookmarksDB))
+	for _, bookmarkDB := range bookmarksDB {
+		chirpsDB = append(chirpsDB, bookmarkDB.Chirp)
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), userId, chirpsDB)
+	if err != nil {
+		respError(w, 500, "Couldn't get bookmarks", err)
+		return
+	}
+
+	respJSON(w, 200, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
@@ -1,151 +1,671 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
-	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/chirptext"
 	"github.com/nurmuh-alhakim18/chirpy/internal/database"
 )
 
-type chirp struct {
-	Id        uuid.UUID `json:"id"`
-	UserId    uuid.UUID `json:"user_id"`
-	Body      string    `json:"body"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-}
+const (
+	// maxChirpBodySize is the most bytes a chirp's body can take up, however
+	// short it looks, as one character can carry any number of combining
+	// marks.
+	maxChirpBodySize = 8 << 10
+	// maxChirpRequestSize is the largest request a chirp can be created or
+	// edited with.
+	maxChirpRequestSize = 64 << 10
+)
 
 // --- CREATE CHIRP ---
 func (cfg *apiConfig) handlerCreateChirp(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
-		Body string `json:"body"`
+		Body          string        `json:"body"`
+		ParentId      uuid.NullUUID `json:"parent_id"`
+		RechirpOf     uuid.NullUUID `json:"rechirp_of"`
+		QuoteOf       uuid.NullUUID `json:"quote_of"`
+		AttachmentIds []uuid.UUID   `json:"attachment_ids"`
+		Poll          *pollParams   `json:"poll"`
+		PublishAt     *time.Time    `json:"publish_at"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxChirpRequestSize)
+
+	var params parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respError(w, 413, "Chirp is too long", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	var nc newChirp
+	var err error
+	if params.RechirpOf.Valid {
+		if params.Body != "" || params.ParentId.Valid || params.QuoteOf.Valid || len(params.AttachmentIds) > 0 || params.Poll != nil || params.PublishAt != nil {
+			respError(w, 400, "Rechirps can't have a body, parent, quote, attachments, poll or publish time", nil)
+			return
+		}
+
+		originalDB, err := cfg.resolveOriginalChirp(r.Context(), userId, params.RechirpOf.UUID)
+		if err != nil {
+			respError(w, 404, "Couldn't find chirp to rechirp", err)
+			return
+		}
+
+		nc = newChirp{
+			params: database.CreateChirpParams{
+				UserID:      userId,
+				RechirpOfID: uuid.NullUUID{UUID: originalDB.ID, Valid: true},
+			},
+			notifyUserId: originalDB.UserID,
+			notifyKind:   notificationRechirp,
+		}
+	} else {
+		nc, err = cfg.prepareChirp(r.Context(), userId, params.Body, params.ParentId, params.QuoteOf, params.AttachmentIds)
+		if err != nil {
+			respChirpError(w, err, "Couldn't create chirp")
+			return
+		}
+
+		if params.Poll != nil {
+			if params.PublishAt != nil {
+				respError(w, 400, "Scheduled chirps can't have a poll", nil)
+				return
+			}
+
+			pollParams, err := validatePoll(*params.Poll)
+			if err != nil {
+				respError(w, 400, err.Error(), err)
+				return
+			}
+
+			nc.poll = &pollParams
+		}
+
+		// A chirp to be published later is kept as a draft until then.
+		if params.PublishAt != nil {
+			if !params.PublishAt.After(time.Now()) {
+				respError(w, 400, "Publish time must be in the future", nil)
+				return
+			}
+
+			draftDB, err := cfg.db.CreateDraft(r.Context(), database.CreateDraftParams{
+				UserID:        userId,
+				Body:          nc.params.Body,
+				ParentID:      nc.params.ParentID,
+				QuoteOfID:     nc.params.QuoteOfID,
+				AttachmentIds: nc.attachmentIds,
+				PublishAt:     sql.NullTime{Time: params.PublishAt.UTC(), Valid: true},
+			})
+
+			if err != nil {
+				respError(w, 500, "Couldn't schedule chirp", err)
+				return
+			}
+
+			respJSON(w, 202, draftFromDB(draftDB))
+			return
+		}
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
 	if err != nil {
-		respError(w, 401, "Couldn't find JWT", err)
+		respError(w, 500, "Couldn't create chirp", err)
 		return
 	}
+	defer tx.Rollback()
 
-	userId, err := auth.ValidateJWT(token, cfg.secretKeyJWT)
+	chirpCreated, mentioned, err := cfg.saveChirp(r.Context(), cfg.db.WithTx(tx), nc)
 	if err != nil {
-		respError(w, 401, "Couldn't validate JWT", err)
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 409, "Chirp already rechirped", err)
+			return
+		}
+
+		respChirpError(w, err, "Couldn't create chirp")
 		return
 	}
 
-	var params parameters
-	decoder := json.NewDecoder(r.Body)
-	if err := decoder.Decode(&params); err != nil {
-		respError(w, 500, "Couldn't decode parameters", err)
+	err = tx.Commit()
+	if err != nil {
+		respError(w, 500, "Couldn't create chirp", err)
 		return
 	}
 
-	cleaned, err := validateChirp(params.Body)
+	chirp, err := cfg.finishChirp(r.Context(), chirpCreated, nc, mentioned)
 	if err != nil {
-		respError(w, http.StatusBadRequest, err.Error(), err)
+		respError(w, 500, "Couldn't create chirp", err)
+		return
+	}
+
+	respJSON(w, 201, chirp)
+}
+
+// newChirp is a validated chirp that's ready to be created, along with what
+// goes with it once it exists.
+type newChirp struct {
+	params        database.CreateChirpParams
+	attachmentIds []uuid.UUID
+	poll          *database.CreatePollParams
+	// Phrases from flag rules found in the body.
+	flagged []string
+	// The author of the chirp being replied to, rechirped or quoted.
+	notifyUserId uuid.UUID
+	notifyKind   string
+}
+
+// chirpInputError is a problem with a new chirp that's the client's fault,
+// reported to them with status.
+type chirpInputError struct {
+	status  int
+	message string
+	err     error
+	// Set when the chirp is too long.
+	length *chirpLength
+}
+
+// chirpLength is included in the error for a chirp that's too long, so
+// clients can show how far over the limit it is.
+type chirpLength struct {
+	Length    int `json:"length"`
+	MaxLength int `json:"max_length"`
+}
+
+func (e *chirpInputError) Error() string {
+	return e.message
+}
+
+// respChirpError responds with the status of a chirpInputError, or a 500
+// with fallback as the message for anything else.
+func respChirpError(w http.ResponseWriter, err error, fallback string) {
+	type lengthErrorResponse struct {
+		Error string `json:"error"`
+		chirpLength
+	}
+
+	var inputErr *chirpInputError
+	if errors.As(err, &inputErr) {
+		if inputErr.length != nil {
+			respJSON(w, inputErr.status, lengthErrorResponse{
+				Error:       inputErr.message,
+				chirpLength: *inputErr.length,
+			})
+			return
+		}
+
+		respError(w, inputErr.status, inputErr.message, inputErr.err)
 		return
 	}
 
-	chirpCreated, err := cfg.db.CreateChirp(r.Context(), database.CreateChirpParams{
-		UserID: userId,
-		Body:   cleaned,
+	respError(w, 500, fallback, err)
+}
+
+// prepareChirp validates a new chirp, resolving the chirps it replies to and
+// quotes. Problems with the input are returned as a *chirpInputError.
+func (cfg *apiConfig) prepareChirp(ctx context.Context, userId uuid.UUID, body string, parentId, quoteOf uuid.NullUUID, attachmentIds []uuid.UUID) (newChirp, error) {
+	body = chirptext.Normalize(body)
+
+	err := cfg.validateChirp(ctx, userId, body)
+	if err != nil {
+		return newChirp{}, err
+	}
+
+	filtered, err := cfg.filterChirp(ctx, body)
+	if err != nil {
+		return newChirp{}, err
+	}
+
+	nc := newChirp{
+		params: database.CreateChirpParams{
+			UserID:   userId,
+			Body:     filtered.Body,
+			ParentID: parentId,
+		},
+		flagged: filtered.Flagged,
+	}
+
+	if quoteOf.Valid {
+		quotedDB, err := cfg.resolveOriginalChirp(ctx, userId, quoteOf.UUID)
+		if err != nil {
+			return newChirp{}, &chirpInputError{status: 404, message: "Couldn't find chirp to quote", err: err}
+		}
+
+		nc.params.QuoteOfID = uuid.NullUUID{UUID: quotedDB.ID, Valid: true}
+		nc.notifyUserId, nc.notifyKind = quotedDB.UserID, notificationQuote
+	}
+
+	if parentId.Valid {
+		// Chirps by users who blocked the author can't be seen, so they can't
+		// be replied to either.
+		parentDB, err := cfg.db.GetVisibleChirpById(ctx, database.GetVisibleChirpByIdParams{
+			ChirpID:  parentId.UUID,
+			ViewerID: userId,
+		})
+		if err != nil || parentDB.IsTombstone {
+			return newChirp{}, &chirpInputError{status: 404, message: "Couldn't find parent chirp", err: err}
+		}
+
+		nc.notifyUserId, nc.notifyKind = parentDB.UserID, notificationReply
+	}
+
+	nc.attachmentIds, err = cfg.checkAttachments(ctx, userId, attachmentIds)
+	if err != nil {
+		return newChirp{}, &chirpInputError{status: 400, message: err.Error(), err: err}
+	}
+
+	return nc, nil
+}
+
+// saveChirp creates nc with q along with everything that goes with it, and
+// returns the users mentioned in it. q should be in a transaction, so a
+// chirp is never left without its attachments, poll or tags. The chirp's
+// sql.ErrNoRows is returned for a rechirp that already exists.
+func (cfg *apiConfig) saveChirp(ctx context.Context, q *database.Queries, nc newChirp) (database.Chirp, []uuid.UUID, error) {
+	chirpCreated, err := q.CreateChirp(ctx, nc.params)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	if len(nc.attachmentIds) > 0 {
+		attached, err := q.AttachToChirp(ctx, database.AttachToChirpParams{
+			ChirpID:       chirpCreated.ID,
+			AttachmentIds: nc.attachmentIds,
+			UserID:        chirpCreated.UserID,
+		})
+
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+
+		// The attachments were checked before, but one may have gone to
+		// another chirp since.
+		if int(attached) != len(nc.attachmentIds) {
+			return database.Chirp{}, nil, &chirpInputError{status: 400, message: "invalid attachments"}
+		}
+	}
+
+	if nc.poll != nil {
+		pollParams := *nc.poll
+		pollParams.ChirpID = chirpCreated.ID
+		err := q.CreatePoll(ctx, pollParams)
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+	}
+
+	if len(nc.flagged) > 0 {
+		err := q.UpsertChirpFlag(ctx, database.UpsertChirpFlagParams{
+			ChirpID: chirpCreated.ID,
+			Phrases: nc.flagged,
+		})
+
+		if err != nil {
+			return database.Chirp{}, nil, err
+		}
+	}
+
+	err = saveChirpTags(ctx, q, chirpCreated.ID, chirpCreated.Body)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	mentioned, err := saveChirpMentions(ctx, q, chirpCreated)
+	if err != nil {
+		return database.Chirp{}, nil, err
+	}
+
+	return chirpCreated, mentioned, nil
+}
+
+// finishChirp notifies the users a chirp saved by saveChirp involves and
+// pushes it to realtime clients, once its transaction has been committed.
+func (cfg *apiConfig) finishChirp(ctx context.Context, chirpCreated database.Chirp, nc newChirp, mentioned []uuid.UUID) (chirp, error) {
+	cfg.notifier.notify(mentioned, chirpCreated.UserID, notificationMention, chirpCreated.ID)
+
+	if nc.notifyUserId != uuid.Nil {
+		cfg.notifier.notify([]uuid.UUID{nc.notifyUserId}, chirpCreated.UserID, nc.notifyKind, chirpCreated.ID)
+	}
+
+	c, err := cfg.buildChirp(ctx, chirpCreated.UserID, chirpCreated)
+	if err != nil {
+		return chirp{}, err
+	}
+
+	cfg.publishChirpCreated(ctx, chirpCreated)
+
+	return c, nil
+}
+
+// resolveOriginalChirp looks up the chirp being rechirped or quoted by
+// viewerId. A rechirp resolves to its original so amplifying it never nests.
+// Chirps the viewer can't see aren't found.
+func (cfg *apiConfig) resolveOriginalChirp(ctx context.Context, viewerId, chirpId uuid.UUID) (database.Chirp, error) {
+	chirpDB, err := cfg.db.GetVisibleChirpById(ctx, database.GetVisibleChirpByIdParams{
+		ChirpID:  chirpId,
+		ViewerID: viewerId,
 	})
 
 	if err != nil {
-		respError(w, 500, "Couldn't create chirp", err)
-		return
+		return database.Chirp{}, err
+	}
+
+	if chirpDB.RechirpOfID.Valid {
+		return cfg.resolveOriginalChirp(ctx, viewerId, chirpDB.RechirpOfID.UUID)
+	}
+
+	if chirpDB.IsTombstone {
+		return database.Chirp{}, errors.New("chirp was deleted")
+	}
+
+	return chirpDB, nil
+}
+
+// checkAttachments makes sure the attachments exist, belong to the user and
+// aren't on another chirp yet, returning them without duplicates.
+func (cfg *apiConfig) checkAttachments(ctx context.Context, userId uuid.UUID, attachmentIds []uuid.UUID) ([]uuid.UUID, error) {
+	if len(attachmentIds) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(attachmentIds))
+	unique := make([]uuid.UUID, 0, len(attachmentIds))
+	for _, attachmentId := range attachmentIds {
+		if _, ok := seen[attachmentId]; ok {
+			continue
+		}
+		seen[attachmentId] = struct{}{}
+		unique = append(unique, attachmentId)
+	}
+
+	if len(unique) > maxAttachmentsCount {
+		return nil, fmt.Errorf("a chirp can have at most %d attachments", maxAttachmentsCount)
+	}
+
+	count, err := cfg.db.CountAttachable(ctx, database.CountAttachableParams{
+		AttachmentIds: unique,
+		UserID:        userId,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if int(count) != len(unique) {
+		return nil, errors.New("invalid attachments")
 	}
 
-	respJSON(w, 201, chirp{
-		Id:        chirpCreated.ID,
-		UserId:    chirpCreated.UserID,
-		Body:      chirpCreated.Body,
-		CreatedAt: chirpCreated.CreatedAt,
-		UpdatedAt: chirpCreated.UpdatedAt,
+	return unique, nil
+}
+
+func saveChirpTags(ctx context.Context, q *database.Queries, chirpId uuid.UUID, body string) error {
+	tags := chirptext.Hashtags(body)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	return q.CreateChirpTags(ctx, database.CreateChirpTagsParams{
+		ChirpID: chirpId,
+		Tags:    tags,
 	})
 }
 
-func validateChirp(body string) (string, error) {
-	maxChirpLength := 140
-	if len(body) > maxChirpLength {
-		return "", errors.New("chirp is too long")
+// tombstoneChirp blanks out a chirp that still has replies, along with
+// everything that was derived from its body, its attachments and its poll.
+// The deleted attachments are returned so their files can be removed once q's
+// transaction commits.
+func tombstoneChirp(ctx context.Context, q *database.Queries, chirpId uuid.UUID) ([]database.DeleteAttachmentsRow, error) {
+	err := q.TombstoneChirp(ctx, chirpId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.DeletePoll(ctx, chirpId)
+	if err != nil {
+		return nil, err
 	}
 
-	badWords := map[string]struct{}{
-		"kerfuffle": {},
-		"sharbert":  {},
-		"fornax":    {},
+	deletedDB, err := q.DeleteAttachments(ctx, chirpId)
+	if err != nil {
+		return nil, err
 	}
 
-	cleanedBody := cleanBody(body, badWords)
+	err = q.DeleteChirpTags(ctx, chirpId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.DeleteMentions(ctx, chirpId)
+	if err != nil {
+		return nil, err
+	}
 
-	return cleanedBody, nil
+	return deletedDB, nil
 }
 
-func cleanBody(body string, badWords map[string]struct{}) string {
-	words := strings.Split(body, " ")
-	for i, word := range words {
-		lowered := strings.ToLower(word)
-		if _, ok := badWords[lowered]; ok {
-			words[i] = "****"
+// saveChirpMentions resolves the @mentions in a chirp against usernames,
+// replacing any it had before, and returns the users mentioned for the
+// first time, to be notified once the mentions are committed. Mentions of
+// unknown usernames, or of users who blocked the author, are left as plain
+// text.
+func saveChirpMentions(ctx context.Context, q *database.Queries, chirpDB database.Chirp) ([]uuid.UUID, error) {
+	previousDB, err := q.GetMentions(ctx, []uuid.UUID{chirpDB.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.DeleteMentions(ctx, chirpDB.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	mentions := chirptext.Mentions(chirpDB.Body)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	usernames := make([]string, 0, len(mentions))
+	for _, m := range mentions {
+		usernames = append(usernames, strings.ToLower(m.Username))
+	}
+
+	usersDB, err := q.GetUsersByUsernames(ctx, database.GetUsersByUsernamesParams{
+		Usernames: usernames,
+		AuthorID:  chirpDB.UserID,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	userIdsByUsername := make(map[string]uuid.UUID, len(usersDB))
+	for _, userDB := range usersDB {
+		userIdsByUsername[strings.ToLower(userDB.Username)] = userDB.ID
+	}
+
+	params := database.CreateMentionsParams{ChirpID: chirpDB.ID}
+	for _, m := range mentions {
+		userId, ok := userIdsByUsername[strings.ToLower(m.Username)]
+		if !ok {
+			continue
 		}
+
+		params.UserIds = append(params.UserIds, userId)
+		params.StartIndexes = append(params.StartIndexes, int32(m.Start))
+		params.EndIndexes = append(params.EndIndexes, int32(m.End))
+	}
+
+	if len(params.UserIds) == 0 {
+		return nil, nil
 	}
 
-	return strings.Join(words, " ")
+	err = q.CreateMentions(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	notified := map[uuid.UUID]struct{}{chirpDB.UserID: {}}
+	for _, previous := range previousDB {
+		notified[previous.UserID] = struct{}{}
+	}
+
+	var recipients []uuid.UUID
+	for _, userId := range params.UserIds {
+		if _, ok := notified[userId]; ok {
+			continue
+		}
+
+		notified[userId] = struct{}{}
+		recipients = append(recipients, userId)
+	}
+
+	return recipients, nil
+}
+
+// validateChirp checks the author isn't suspended and body fits within their
+// length limit, which is higher for Chirpy Red members. A chirp that's too
+// long is returned as a *chirpInputError with the measured length, unless
+// it's over maxChirpBodySize and isn't measured at all. Words are checked
+// separately by filterChirp.
+func (cfg *apiConfig) validateChirp(ctx context.Context, userId uuid.UUID, body string) error {
+	userDB, err := cfg.db.GetUserById(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	// Access tokens outlive a suspension by up to an hour, so it's checked
+	// here too.
+	if userDB.SuspendedAt.Valid {
+		return &chirpInputError{status: 403, message: "Account is suspended"}
+	}
+
+	if len(body) > maxChirpBodySize {
+		return &chirpInputError{status: 400, message: "Chirp is too long"}
+	}
+
+	maxLength := cfg.maxChirpLength
+	if userDB.IsChirpyRed {
+		maxLength = cfg.maxChirpLengthRed
+	}
+
+	length := chirptext.Length(body)
+	if length > maxLength {
+		return &chirpInputError{
+			status:  400,
+			message: "Chirp is too long",
+			length:  &chirpLength{Length: length, MaxLength: maxLength},
+		}
+	}
+
+	return nil
 }
 
 // --- GET ALL CHIRPS ---
 func (cfg *apiConfig) handlerGetAllChirps(w http.ResponseWriter, r *http.Request) {
-	chirpsDB, err := cfg.db.GetAllChirps(r.Context())
+	type response struct {
+		Chirps     []chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	query := r.URL.Query()
+
+	viewerId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
 	if err != nil {
-		respError(w, 500, "Couldn't get chirps", err)
+		respError(w, 400, err.Error(), err)
 		return
 	}
 
-	var authorId uuid.UUID
-	authorIdString := r.URL.Query().Get("author_id")
+	var authorId uuid.NullUUID
+	authorIdString := query.Get("author_id")
 	if authorIdString != "" {
-		authorId, err = uuid.Parse(authorIdString)
+		authorId.UUID, err = uuid.Parse(authorIdString)
 		if err != nil {
-			respError(w, 500, "Couldn't parse author id", err)
+			respError(w, 400, "Couldn't parse author id", err)
 			return
 		}
+		authorId.Valid = true
 	}
 
-	sortDir := "asc"
-	sortQuery := r.URL.Query().Get("sort")
-	if sortQuery == "desc" {
-		sortDir = "desc"
+	var chirpsDB []database.Chirp
+	if query.Get("sort") == "desc" {
+		chirpsDB, err = cfg.db.GetChirpsDesc(r.Context(), database.GetChirpsDescParams{
+			AuthorID:        authorId,
+			ViewerID:        viewerId,
+			CursorCreatedAt: p.cursorCreatedAt,
+			CursorID:        p.cursorId,
+			Limit:           p.fetchLimit(),
+		})
+	} else {
+		chirpsDB, err = cfg.db.GetChirps(r.Context(), database.GetChirpsParams{
+			AuthorID:        authorId,
+			ViewerID:        viewerId,
+			CursorCreatedAt: p.cursorCreatedAt,
+			CursorID:        p.cursorId,
+			Limit:           p.fetchLimit(),
+		})
 	}
 
-	var chirps []chirp
-	for _, chirpDB := range chirpsDB {
-		if authorId != uuid.Nil && chirpDB.UserID != authorId {
-			continue
-		}
+	if err != nil {
+		respError(w, 500, "Couldn't get chirps", err)
+		return
+	}
 
-		chirps = append(chirps, chirp{
-			Id:        chirpDB.ID,
-			UserId:    chirpDB.UserID,
-			Body:      chirpDB.Body,
-			CreatedAt: chirpDB.CreatedAt,
-			UpdatedAt: chirpDB.UpdatedAt,
-		})
+	var nextCursor string
+	if len(chirpsDB) > p.limit {
+		chirpsDB = chirpsDB[:p.limit]
+		last := chirpsDB[len(chirpsDB)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
 	}
 
-	if sortDir == "desc" {
-		sort.Slice(chirps, func(i, j int) bool {
-			return chirps[i].CreatedAt.After(chirps[j].CreatedAt)
+	// An author's pinned chirps come first on the first page. The queries
+	// leave them out of the rest, so they're never repeated.
+	var pinnedDB []database.Chirp
+	if authorId.Valid && !p.cursorId.Valid {
+		pinnedDB, err = cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+			UserID:   authorId.UUID,
+			ViewerID: viewerId,
 		})
+
+		if err != nil {
+			respError(w, 500, "Couldn't get chirps", err)
+			return
+		}
+
+		chirpsDB = append(pinnedDB, chirpsDB...)
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), viewerId, chirpsDB)
+	if err != nil {
+		respError(w, 500, "Couldn't get chirps", err)
+		return
 	}
 
-	respJSON(w, 200, chirps)
+	for i := range pinnedDB {
+		chirps[i].Pinned = true
+	}
+
+	respJSON(w, 200, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
 }
 
 // --- GET CHIRP BY ID ---
@@ -157,21 +677,306 @@ func (cfg *apiConfig) handlerGetChirpById(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	chirpDB, err := cfg.db.GetChirpById(r.Context(), chirpID)
+	viewerId := auth.UserIdFromContext(r.Context())
+
+	chirpDB, err := cfg.db.GetVisibleChirpById(r.Context(), database.GetVisibleChirpByIdParams{
+		ChirpID:  chirpID,
+		ViewerID: viewerId,
+	})
+	if err != nil || chirpDB.IsTombstone {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), viewerId, chirpDB)
+	if err != nil {
+		respError(w, 500, "Couldn't get chirp", err)
+		return
+	}
+
+	respJSON(w, 200, chirp)
+}
+
+// --- UPDATE CHIRP ---
+func (cfg *apiConfig) handlerUpdateChirp(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Body string `json:"body"`
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
 	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxChirpRequestSize)
+
+	var params parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respError(w, 413, "Chirp is too long", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	chirpDB, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil || chirpDB.IsTombstone {
 		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	if chirpDB.UserID != userId {
+		respError(w, 403, "Couldn't update chirp", nil)
+		return
+	}
+
+	if chirpDB.RechirpOfID.Valid {
+		respError(w, 400, "Rechirps can't be edited", nil)
+		return
+	}
+
+	body := chirptext.Normalize(params.Body)
+
+	err = cfg.validateChirp(r.Context(), userId, body)
+	if err != nil {
+		respChirpError(w, err, "Couldn't update chirp")
+		return
+	}
+
+	filtered, err := cfg.filterChirp(r.Context(), body)
+	if err != nil {
+		respChirpError(w, err, "Couldn't update chirp")
+		return
+	}
+
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respError(w, 500, "Couldn't update chirp", err)
+		return
+	}
+	defer tx.Rollback()
+
+	q := cfg.db.WithTx(tx)
+
+	chirpUpdated, err := q.UpdateChirpBody(r.Context(), database.UpdateChirpBodyParams{
+		Body: filtered.Body,
+		ID:   chirpId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't update chirp", err)
+		return
+	}
+
+	// The flag follows the current body, so an edit can add or clear it.
+	if len(filtered.Flagged) > 0 {
+		err = q.UpsertChirpFlag(r.Context(), database.UpsertChirpFlagParams{
+			ChirpID: chirpId,
+			Phrases: filtered.Flagged,
+		})
+	} else {
+		_, err = q.DeleteChirpFlag(r.Context(), chirpId)
+	}
+
+	if err != nil {
+		respError(w, 500, "Couldn't update chirp", err)
+		return
+	}
+
+	err = q.DeleteChirpTags(r.Context(), chirpId)
+	if err != nil {
+		respError(w, 500, "Couldn't save hashtags", err)
+		return
+	}
+
+	err = saveChirpTags(r.Context(), q, chirpId, chirpUpdated.Body)
+	if err != nil {
+		respError(w, 500, "Couldn't save hashtags", err)
+		return
+	}
+
+	mentioned, err := saveChirpMentions(r.Context(), q, chirpUpdated)
+	if err != nil {
+		respError(w, 500, "Couldn't save mentions", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respError(w, 500, "Couldn't update chirp", err)
+		return
+	}
+
+	cfg.notifier.notify(mentioned, userId, notificationMention, chirpId)
+
+	chirp, err := cfg.buildChirp(r.Context(), userId, chirpUpdated)
+	if err != nil {
+		respError(w, 500, "Couldn't update chirp", err)
+		return
 	}
 
-	respJSON(w, 200, chirp{
-		Id:        chirpDB.ID,
-		UserId:    chirpDB.UserID,
+	respJSON(w, 200, chirp)
+}
+
+// --- GET CHIRP HISTORY ---
+func (cfg *apiConfig) handlerGetChirpHistory(w http.ResponseWriter, r *http.Request) {
+	type revision struct {
+		Body      string    `json:"body"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	chirpDB, err := cfg.db.GetVisibleChirpById(r.Context(), database.GetVisibleChirpByIdParams{
+		ChirpID:  chirpId,
+		ViewerID: auth.UserIdFromContext(r.Context()),
+	})
+
+	if err != nil || chirpDB.IsTombstone {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	revisionsDB, err := cfg.db.GetChirpRevisions(r.Context(), chirpId)
+	if err != nil {
+		respError(w, 500, "Couldn't get chirp history", err)
+		return
+	}
+
+	// The current body comes first, followed by earlier ones, newest first.
+	current := revision{
 		Body:      chirpDB.Body,
 		CreatedAt: chirpDB.CreatedAt,
-		UpdatedAt: chirpDB.UpdatedAt,
+	}
+	if chirpDB.EditedAt.Valid {
+		current.CreatedAt = chirpDB.EditedAt.Time
+	}
+
+	revisions := []revision{current}
+	for _, revisionDB := range revisionsDB {
+		revisions = append(revisions, revision{
+			Body:      revisionDB.Body,
+			CreatedAt: revisionDB.CreatedAt,
+		})
+	}
+
+	respJSON(w, 200, revisions)
+}
+
+// --- GET CHIRP THREAD ---
+const maxThreadReplies = 500
+
+type threadNode struct {
+	chirp
+	Replies []threadNode `json:"replies"`
+}
+
+func (cfg *apiConfig) handlerGetChirpThread(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Ancestors []chirp      `json:"ancestors"`
+		Chirp     chirp        `json:"chirp"`
+		Replies   []threadNode `json:"replies"`
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	viewerId := auth.UserIdFromContext(r.Context())
+
+	chirpDB, err := cfg.db.GetVisibleChirpById(r.Context(), database.GetVisibleChirpByIdParams{
+		ChirpID:  chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	ancestorsDB, err := cfg.db.GetChirpAncestors(r.Context(), database.GetChirpAncestorsParams{
+		ChirpID:  chirpId,
+		ViewerID: viewerId,
+	})
+	if err != nil {
+		respError(w, 500, "Couldn't get thread", err)
+		return
+	}
+
+	descendantsDB, err := cfg.db.GetChirpDescendants(r.Context(), database.GetChirpDescendantsParams{
+		ChirpID:  chirpId,
+		ViewerID: viewerId,
+		Limit:    maxThreadReplies,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get thread", err)
+		return
+	}
+
+	threadDB := make([]database.Chirp, 0, len(ancestorsDB)+1+len(descendantsDB))
+	threadDB = append(threadDB, ancestorsDB...)
+	threadDB = append(threadDB, chirpDB)
+	threadDB = append(threadDB, descendantsDB...)
+
+	thread, err := cfg.buildChirps(r.Context(), viewerId, threadDB)
+	if err != nil {
+		respError(w, 500, "Couldn't get thread", err)
+		return
+	}
+
+	ancestors := thread[:len(ancestorsDB)]
+	root := thread[len(ancestorsDB)]
+	descendants := thread[len(ancestorsDB)+1:]
+
+	respJSON(w, 200, response{
+		Ancestors: ancestors,
+		Chirp:     root,
+		Replies:   buildReplyTree(root.Id, descendants),
 	})
 }
 
+// buildReplyTree nests replies under their parents. Descendants arrive in
+// creation order, so siblings stay oldest first.
+func buildReplyTree(rootId uuid.UUID, descendants []chirp) []threadNode {
+	children := make(map[uuid.UUID][]chirp)
+	for _, descendant := range descendants {
+		children[*descendant.ParentId] = append(children[*descendant.ParentId], descendant)
+	}
+
+	var build func(parentId uuid.UUID) []threadNode
+	build = func(parentId uuid.UUID) []threadNode {
+		nodes := make([]threadNode, 0, len(children[parentId]))
+		for _, child := range children[parentId] {
+			nodes = append(nodes, threadNode{
+				chirp:   child,
+				Replies: build(child.Id),
+			})
+		}
+		return nodes
+	}
+
+	return build(rootId)
+}
+
 // --- DELETE CHIRP ---
+type deletedChirp struct {
+	Id     uuid.UUID `json:"id"`
+	UserId uuid.UUID `json:"user_id"`
+}
+
 func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request) {
 	chirpIdString := r.PathValue("chirpId")
 	chirpId, err := uuid.Parse(chirpIdString)
@@ -180,33 +985,107 @@ func (cfg *apiConfig) handlerDeleteChirp(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
+	userId := auth.UserIdFromContext(r.Context())
+
+	chirpDB, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil || chirpDB.IsTombstone {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	if chirpDB.UserID != userId {
+		respError(w, 403, "Couldn't delete chirp", err)
+		return
+	}
+
+	// Chirps are only marked as deleted so they can be restored for a while,
+	// and purged for good later. Rechirps have nothing worth restoring, so
+	// undoing one removes it straight away.
+	if chirpDB.RechirpOfID.Valid {
+		err = cfg.db.DeleteChirp(r.Context(), chirpId)
+	} else {
+		err = cfg.db.SoftDeleteChirp(r.Context(), chirpId)
+	}
+
 	if err != nil {
-		respError(w, 401, "Couldn't find JWT", err)
+		respError(w, 500, "Couldn't delete chirp", err)
 		return
 	}
 
-	userId, err := auth.ValidateJWT(token, cfg.secretKeyJWT)
+	// Free up the pin slot rather than let a deleted chirp hold onto it.
+	err = cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+
 	if err != nil {
-		respError(w, 401, "Couldn't validate JWT", err)
+		respError(w, 500, "Couldn't delete chirp", err)
 		return
 	}
 
-	chirpDB, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	cfg.broker.publish(event{
+		Kind:     eventChirpDeleted,
+		UserId:   userId,
+		ChirpId:  chirpId,
+		ParentId: chirpDB.ParentID.UUID,
+	}, deletedChirp{
+		Id:     chirpId,
+		UserId: userId,
+	})
+
+	w.WriteHeader(204)
+}
+
+// --- RESTORE CHIRP ---
+func (cfg *apiConfig) handlerRestoreChirp(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
 	if err != nil {
-		respError(w, 404, "Couldn't get chirp", err)
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	chirpDB, err := cfg.db.GetDeletedChirpById(r.Context(), chirpId)
+	if err != nil || chirpDB.IsTombstone {
+		respError(w, 404, "Couldn't find deleted chirp", err)
 		return
 	}
 
 	if chirpDB.UserID != userId {
-		respError(w, 403, "Couldn't delete chirp", err)
+		respError(w, 403, "Couldn't restore chirp", nil)
 		return
 	}
 
-	err = cfg.db.DeleteChirp(r.Context(), chirpId)
+	restored, err := cfg.db.RestoreChirp(r.Context(), database.RestoreChirpParams{
+		ChirpID:       chirpId,
+		WindowSeconds: chirpRestoreWindow.Seconds(),
+	})
+
 	if err != nil {
-		respError(w, 500, "Couldn't delete chirp", err)
+		respError(w, 500, "Couldn't restore chirp", err)
+		return
 	}
 
-	w.WriteHeader(204)
+	if restored == 0 {
+		respError(w, 410, "Chirp can no longer be restored", nil)
+		return
+	}
+
+	chirpDB, err = cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil {
+		respError(w, 500, "Couldn't restore chirp", err)
+		return
+	}
+
+	chirp, err := cfg.buildChirp(r.Context(), userId, chirpDB)
+	if err != nil {
+		respError(w, 500, "Couldn't restore chirp", err)
+		return
+	}
+
+	// To realtime clients a restored chirp looks just like a new one.
+	cfg.publishChirpCreated(r.Context(), chirpDB)
+
+	respJSON(w, 200, chirp)
 }
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// draftParameters is the body of a create or update draft request.
+type draftParameters struct {
+	Body          string        `json:"body"`
+	ParentId      uuid.NullUUID `json:"parent_id"`
+	QuoteOf       uuid.NullUUID `json:"quote_of"`
+	AttachmentIds []uuid.UUID   `json:"attachment_ids"`
+	PublishAt     *time.Time    `json:"publish_at"`
+}
+
+// validateDraft checks a draft the same way as a chirp about to be
+// published, so scheduled drafts only fail to publish if something changes
+// in the meantime.
+func (cfg *apiConfig) validateDraft(r *http.Request, userId uuid.UUID, params draftParameters) (newChirp, sql.NullTime, error) {
+	nc, err := cfg.prepareChirp(r.Context(), userId, params.Body, params.ParentId, params.QuoteOf, params.AttachmentIds)
+	if err != nil {
+		return newChirp{}, sql.NullTime{}, err
+	}
+
+	if params.PublishAt == nil {
+		return nc, sql.NullTime{}, nil
+	}
+
+	if !params.PublishAt.After(time.Now()) {
+		return newChirp{}, sql.NullTime{}, &chirpInputError{status: 400, message: "Publish time must be in the future"}
+	}
+
+	return nc, sql.NullTime{Time: params.PublishAt.UTC(), Valid: true}, nil
+}
+
+// --- CREATE DRAFT ---
+func (cfg *apiConfig) handlerCreateDraft(w http.ResponseWriter, r *http.Request) {
+	userId := auth.UserIdFromContext(r.Context())
+
+	var params draftParameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	nc, publishAt, err := cfg.validateDraft(r, userId, params)
+	if err != nil {
+		respChirpError(w, err, "Couldn't create draft")
+		return
+	}
+
+	draftDB, err := cfg.db.CreateDraft(r.Context(), database.CreateDraftParams{
+		UserID:        userId,
+		Body:          nc.params.Body,
+		ParentID:      nc.params.ParentID,
+		QuoteOfID:     nc.params.QuoteOfID,
+		AttachmentIds: nc.attachmentIds,
+		PublishAt:     publishAt,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't create draft", err)
+		return
+	}
+
+	respJSON(w, 201, draftFromDB(draftDB))
+}
+
+// --- GET DRAFTS ---
+func (cfg *apiConfig) handlerGetDrafts(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Drafts     []draft `json:"drafts"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	draftsDB, err := cfg.db.GetDrafts(r.Context(), database.GetDraftsParams{
+		UserID:          userId,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get drafts", err)
+		return
+	}
+
+	var nextCursor string
+	if len(draftsDB) > p.limit {
+		draftsDB = draftsDB[:p.limit]
+		last := draftsDB[len(draftsDB)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	drafts := make([]draft, 0, len(draftsDB))
+	for _, draftDB := range draftsDB {
+		drafts = append(drafts, draftFromDB(draftDB))
+	}
+
+	respJSON(w, 200, response{
+		Drafts:     drafts,
+		NextCursor: nextCursor,
+	})
+}
+
+// getOwnDraft loads the draft in the request path, responding with an error
+// and returning false unless it belongs to the authenticated user.
+func (cfg *apiConfig) getOwnDraft(w http.ResponseWriter, r *http.Request) (database.Draft, bool) {
+	draftId, err := uuid.Parse(r.PathValue("draftId"))
+	if err != nil {
+		respError(w, 400, "Invalid draft ID", err)
+		return database.Draft{}, false
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	draftDB, err := cfg.db.GetDraftById(r.Context(), draftId)
+	if err != nil {
+		respError(w, 404, "Couldn't get draft", err)
+		return database.Draft{}, false
+	}
+
+	// Drafts are private, so other users' drafts don't exist as far as the
+	// caller knows.
+	if draftDB.UserID != userId {
+		respError(w, 404, "Couldn't get draft", nil)
+		return database.Draft{}, false
+	}
+
+	return draftDB, true
+}
+
+// --- GET DRAFT BY ID ---
+func (cfg *apiConfig) handlerGetDraftById(w http.ResponseWriter, r *http.Request) {
+	draftDB, ok := cfg.getOwnDraft(w, r)
+	if !ok {
+		return
+	}
+
+	respJSON(w, 200, draftFromDB(draftDB))
+}
+
+// --- UPDATE DRAFT ---
+func (cfg *apiConfig) handlerUpdateDraft(w http.ResponseWriter, r *http.Request) {
+	draftDB, ok := cfg.getOwnDraft(w, r)
+	if !ok {
+		return
+	}
+
+	var params draftParameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	nc, publishAt, err := cfg.validateDraft(r, draftDB.UserID, params)
+	if err != nil {
+		respChirpError(w, err, "Couldn't update draft")
+		return
+	}
+
+	updatedDB, err := cfg.db.UpdateDraft(r.Context(), database.UpdateDraftParams{
+		ID:            draftDB.ID,
+		Body:          nc.params.Body,
+		ParentID:      nc.params.ParentID,
+		QuoteOfID:     nc.params.QuoteOfID,
+		AttachmentIds: nc.attachmentIds,
+		PublishAt:     publishAt,
+	})
+
+	if err != nil {
+		// The scheduler got to it first.
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't get draft", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't update draft", err)
+		return
+	}
+
+	respJSON(w, 200, draftFromDB(updatedDB))
+}
+
+// --- DELETE DRAFT ---
+func (cfg *apiConfig) handlerDeleteDraft(w http.ResponseWriter, r *http.Request) {
+	draftDB, ok := cfg.getOwnDraft(w, r)
+	if !ok {
+		return
+	}
+
+	_, err := cfg.db.DeleteDraft(r.Context(), draftDB.ID)
+	if err != nil {
+		respError(w, 500, "Couldn't delete draft", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- PUBLISH DRAFT ---
+func (cfg *apiConfig) handlerPublishDraft(w http.ResponseWriter, r *http.Request) {
+	draftDB, ok := cfg.getOwnDraft(w, r)
+	if !ok {
+		return
+	}
+
+	_, chirp, err := cfg.publishDraft(r.Context(), func(q *database.Queries) (database.Draft, error) {
+		return q.LockDraft(r.Context(), draftDB.ID)
+	})
+
+	if err != nil {
+		// The scheduler published it while we were waiting for the lock.
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't get draft", err)
+			return
+		}
+
+		respChirpError(w, err, "Couldn't publish draft")
+		return
+	}
+
+	respJSON(w, 201, chirp)
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+	"github.com/nurmuh-alhakim18/chirpy/internal/filter"
+)
+
+// filterRuleParameters is the body of a create or update filter rule
+// request.
+type filterRuleParameters struct {
+	Phrase string        `json:"phrase"`
+	Action filter.Action `json:"action"`
+}
+
+// validateFilterRule returns the phrase to store for params, or an error
+// message for the client.
+func validateFilterRule(params filterRuleParameters) (string, string) {
+	phrase := filter.Normalize(params.Phrase)
+	if phrase == "" {
+		return "", "Phrase must contain at least one word"
+	}
+
+	if !params.Action.Valid() {
+		return "", "Action must be mask, reject or flag"
+	}
+
+	return phrase, ""
+}
+
+// --- GET FILTER RULES ---
+func (cfg *apiConfig) handlerGetFilterRules(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Rules []filterRule `json:"rules"`
+	}
+
+	rulesDB, err := cfg.db.GetFilterRules(r.Context())
+	if err != nil {
+		respError(w, 500, "Couldn't get filter rules", err)
+		return
+	}
+
+	rules := make([]filterRule, 0, len(rulesDB))
+	for _, ruleDB := range rulesDB {
+		rules = append(rules, filterRuleFromDB(ruleDB))
+	}
+
+	respJSON(w, 200, response{Rules: rules})
+}
+
+// --- CREATE FILTER RULE ---
+func (cfg *apiConfig) handlerCreateFilterRule(w http.ResponseWriter, r *http.Request) {
+	var params filterRuleParameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	phrase, msg := validateFilterRule(params)
+	if msg != "" {
+		respError(w, 400, msg, nil)
+		return
+	}
+
+	ruleDB, err := cfg.db.CreateFilterRule(r.Context(), database.CreateFilterRuleParams{
+		Phrase: phrase,
+		Action: string(params.Action),
+	})
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			respError(w, 409, "A rule for this phrase already exists", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't create filter rule", err)
+		return
+	}
+
+	respJSON(w, 201, filterRuleFromDB(ruleDB))
+}
+
+// --- UPDATE FILTER RULE ---
+func (cfg *apiConfig) handlerUpdateFilterRule(w http.ResponseWriter, r *http.Request) {
+	ruleId, err := uuid.Parse(r.PathValue("ruleId"))
+	if err != nil {
+		respError(w, 400, "Invalid rule ID", err)
+		return
+	}
+
+	var params filterRuleParameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	phrase, msg := validateFilterRule(params)
+	if msg != "" {
+		respError(w, 400, msg, nil)
+		return
+	}
+
+	ruleDB, err := cfg.db.UpdateFilterRule(r.Context(), database.UpdateFilterRuleParams{
+		ID:     ruleId,
+		Phrase: phrase,
+		Action: string(params.Action),
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't find filter rule", err)
+			return
+		}
+		if isUniqueViolation(err) {
+			respError(w, 409, "A rule for this phrase already exists", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't update filter rule", err)
+		return
+	}
+
+	respJSON(w, 200, filterRuleFromDB(ruleDB))
+}
+
+// --- DELETE FILTER RULE ---
+func (cfg *apiConfig) handlerDeleteFilterRule(w http.ResponseWriter, r *http.Request) {
+	ruleId, err := uuid.Parse(r.PathValue("ruleId"))
+	if err != nil {
+		respError(w, 400, "Invalid rule ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteFilterRule(r.Context(), ruleId)
+	if err != nil {
+		respError(w, 500, "Couldn't delete filter rule", err)
+		return
+	}
+
+	if deleted == 0 {
+		respError(w, 404, "Couldn't find filter rule", nil)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- GET FLAGGED CHIRPS ---
+func (cfg *apiConfig) handlerGetFlaggedChirps(w http.ResponseWriter, r *http.Request) {
+	type flaggedChirp struct {
+		Chirp     chirp     `json:"chirp"`
+		Phrases   []string  `json:"phrases"`
+		FlaggedAt time.Time `json:"flagged_at"`
+	}
+
+	type response struct {
+		Chirps     []flaggedChirp `json:"chirps"`
+		NextCursor string         `json:"next_cursor,omitempty"`
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	// Oldest first, so the review queue is worked through in order.
+	flaggedDB, err := cfg.db.GetFlaggedChirps(r.Context(), database.GetFlaggedChirpsParams{
+		CursorFlaggedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get flagged chirps", err)
+		return
+	}
+
+	var nextCursor string
+	if len(flaggedDB) > p.limit {
+		flaggedDB = flaggedDB[:p.limit]
+		last := flaggedDB[len(flaggedDB)-1]
+		nextCursor = encodeCursor(last.FlaggedAt, last.Chirp.ID)
+	}
+
+	chirpsDB := make([]database.Chirp, 0, len(flaggedDB))
+	for _, f := range flaggedDB {
+		chirpsDB = append(chirpsDB, f.Chirp)
+	}
+
+	chirps, err := cfg.buildChirpsForReview(r.Context(), chirpsDB)
+	if err != nil {
+		respError(w, 500, "Couldn't get flagged chirps", err)
+		return
+	}
+
+	flagged := make([]flaggedChirp, 0, len(flaggedDB))
+	for i, f := range flaggedDB {
+		flagged = append(flagged, flaggedChirp{
+			Chirp:     chirps[i],
+			Phrases:   f.Phrases,
+			FlaggedAt: f.FlaggedAt,
+		})
+	}
+
+	respJSON(w, 200, response{
+		Chirps:     flagged,
+		NextCursor: nextCursor,
+	})
+}
+
+// --- DISMISS CHIRP FLAG ---
+func (cfg *apiConfig) handlerDismissChirpFlag(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	deleted, err := cfg.db.DeleteChirpFlag(r.Context(), chirpId)
+	if err != nil {
+		respError(w, 500, "Couldn't dismiss flag", err)
+		return
+	}
+
+	if deleted == 0 {
+		respError(w, 404, "Couldn't find flagged chirp", nil)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- GET MUTED KEYWORDS ---
+func (cfg *apiConfig) handlerGetMutedKeywords(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Keywords []mutedKeyword `json:"keywords"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	keywordsDB, err := cfg.db.GetMutedKeywords(r.Context(), userId)
+	if err != nil {
+		respError(w, 500, "Couldn't get muted keywords", err)
+		return
+	}
+
+	keywords := make([]mutedKeyword, 0, len(keywordsDB))
+	for _, keywordDB := range keywordsDB {
+		keywords = append(keywords, mutedKeyword{
+			Keyword:   keywordDB.Keyword,
+			CreatedAt: keywordDB.CreatedAt,
+		})
+	}
+
+	respJSON(w, 200, response{Keywords: keywords})
+}
+
+// --- MUTE KEYWORD ---
+func (cfg *apiConfig) handlerMuteKeyword(w http.ResponseWriter, r *http.Request) {
+	userId := auth.UserIdFromContext(r.Context())
+
+	keyword := filter.Normalize(r.PathValue("keyword"))
+	if keyword == "" || utf8.RuneCountInString(keyword) > maxMutedKeywordLength {
+		respError(w, 400, fmt.Sprintf("Keyword must be 1 to %d characters", maxMutedKeywordLength), nil)
+		return
+	}
+
+	count, err := cfg.db.CountMutedKeywords(r.Context(), userId)
+	if err != nil {
+		respError(w, 500, "Couldn't mute keyword", err)
+		return
+	}
+
+	if count >= maxMutedKeywords {
+		respError(w, 409, fmt.Sprintf("Can't mute more than %d keywords", maxMutedKeywords), nil)
+		return
+	}
+
+	_, err = cfg.db.CreateMutedKeyword(r.Context(), database.CreateMutedKeywordParams{
+		UserID:  userId,
+		Keyword: keyword,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't mute keyword", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- UNMUTE KEYWORD ---
+func (cfg *apiConfig) handlerUnmuteKeyword(w http.ResponseWriter, r *http.Request) {
+	userId := auth.UserIdFromContext(r.Context())
+
+	err := cfg.db.DeleteMutedKeyword(r.Context(), database.DeleteMutedKeywordParams{
+		UserID:  userId,
+		Keyword: filter.Normalize(r.PathValue("keyword")),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't unmute keyword", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+type follow struct {
+	publicUser
+	FollowedAt time.Time `json:"followed_at"`
+}
+
+// --- FOLLOW USER ---
+func (cfg *apiConfig) handlerFollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respError(w, 400, "Invalid user ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	if followeeId == userId {
+		respError(w, 400, "Couldn't follow yourself", nil)
+		return
+	}
+
+	_, err = cfg.db.GetUserById(r.Context(), followeeId)
+	if err != nil {
+		respError(w, 404, "Couldn't find user", err)
+		return
+	}
+
+	blocked, err := cfg.db.IsBlockedBetween(r.Context(), database.IsBlockedBetweenParams{
+		UserID:  userId,
+		OtherID: followeeId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't follow user", err)
+		return
+	}
+
+	if blocked {
+		respError(w, 403, "Couldn't follow user", nil)
+		return
+	}
+
+	followed, err := cfg.db.CreateFollow(r.Context(), database.CreateFollowParams{
+		FollowerID: userId,
+		FolloweeID: followeeId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't follow user", err)
+		return
+	}
+
+	if followed > 0 {
+		cfg.notifier.notify([]uuid.UUID{followeeId}, userId, notificationFollow, uuid.Nil)
+	}
+
+	cfg.relationsChanged(userId)
+
+	w.WriteHeader(204)
+}
+
+// --- UNFOLLOW USER ---
+func (cfg *apiConfig) handlerUnfollowUser(w http.ResponseWriter, r *http.Request) {
+	followeeId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respError(w, 400, "Invalid user ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	err = cfg.db.DeleteFollow(r.Context(), database.DeleteFollowParams{
+		FollowerID: userId,
+		FolloweeID: followeeId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't unfollow user", err)
+		return
+	}
+
+	cfg.relationsChanged(userId)
+
+	w.WriteHeader(204)
+}
+
+// --- GET FOLLOWERS ---
+func (cfg *apiConfig) handlerGetFollowers(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []follow `json:"users"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respError(w, 400, "Invalid user ID", err)
+		return
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	followersDB, err := cfg.db.GetFollowers(r.Context(), database.GetFollowersParams{
+		UserID:           userId,
+		CursorFollowedAt: p.cursorCreatedAt,
+		CursorID:         p.cursorId,
+		Limit:            p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get followers", err)
+		return
+	}
+
+	var nextCursor string
+	if len(followersDB) > p.limit {
+		followersDB = followersDB[:p.limit]
+		last := followersDB[len(followersDB)-1]
+		nextCursor = encodeCursor(last.FollowedAt, last.ID)
+	}
+
+	followers := make([]follow, 0, len(followersDB))
+	for _, followerDB := range followersDB {
+		followers = append(followers, follow{
+			publicUser: publicUser{
+				Id:          followerDB.ID,
+				Username:    followerDB.Username.String,
+				DisplayName: followerDB.DisplayName,
+				AvatarURL:   cfg.avatarKeyURL(followerDB.AvatarKey),
+				IsChirpyRed: followerDB.IsChirpyRed,
+				CreatedAt:   followerDB.CreatedAt,
+			},
+			FollowedAt: followerDB.FollowedAt,
+		})
+	}
+
+	respJSON(w, 200, response{
+		Users:      followers,
+		NextCursor: nextCursor,
+	})
+}
+
+// --- GET FOLLOWING ---
+func (cfg *apiConfig) handlerGetFollowing(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Users      []follow `json:"users"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respError(w, 400, "Invalid user ID", err)
+		return
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	followingDB, err := cfg.db.GetFollowing(r.Context(), database.GetFollowingParams{
+		UserID:           userId,
+		CursorFollowedAt: p.cursorCreatedAt,
+		CursorID:         p.cursorId,
+		Limit:            p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get following", err)
+		return
+	}
+
+	var nextCursor string
+	if len(followingDB) > p.limit {
+		followingDB = followingDB[:p.limit]
+		last := followingDB[len(followingDB)-1]
+		nextCursor = encodeCursor(last.FollowedAt, last.ID)
+	}
+
+	following := make([]follow, 0, len(followingDB))
+	for _, followeeDB := range followingDB {
+		following = append(following, follow{
+			publicUser: publicUser{
+				Id:          followeeDB.ID,
+				Username:    followeeDB.Username.String,
+				DisplayName: followeeDB.DisplayName,
+				AvatarURL:   cfg.avatarKeyURL(followeeDB.AvatarKey),
+				IsChirpyRed: followeeDB.IsChirpyRed,
+				CreatedAt:   followeeDB.CreatedAt,
+			},
+			FollowedAt: followeeDB.FollowedAt,
+		})
+	}
+
+	respJSON(w, 200, response{
+		Users:      following,
+		NextCursor: nextCursor,
+	})
+}
+
+// --- GET TIMELINE ---
+func (cfg *apiConfig) handlerGetTimeline(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	// The timeline holds the user's own chirps plus those of everyone they
+	// follow, newest first.
+	chirpsDB, err := cfg.db.GetTimeline(r.Context(), database.GetTimelineParams{
+		UserID:          userId,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get timeline", err)
+		return
+	}
+
+	var nextCursor string
+	if len(chirpsDB) > p.limit {
+		chirpsDB = chirpsDB[:p.limit]
+		last := chirpsDB[len(chirpsDB)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), userId, chirpsDB)
+	if err != nil {
+		respError(w, 500, "Couldn't get timeline", err)
+		return
+	}
+
+	respJSON(w, 200, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
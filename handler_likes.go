@@ -0,0 +1,69 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// --- LIKE CHIRP ---
+func (cfg *apiConfig) handlerLikeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	chirpDB, err := cfg.db.GetVisibleChirpById(r.Context(), database.GetVisibleChirpByIdParams{
+		ChirpID:  chirpId,
+		ViewerID: userId,
+	})
+
+	if err != nil || chirpDB.IsTombstone {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	liked, err := cfg.db.CreateLike(r.Context(), database.CreateLikeParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't like chirp", err)
+		return
+	}
+
+	if liked > 0 {
+		cfg.notifier.notify([]uuid.UUID{chirpDB.UserID}, userId, notificationLike, chirpId)
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- UNLIKE CHIRP ---
+func (cfg *apiConfig) handlerUnlikeChirp(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	err = cfg.db.DeleteLike(r.Context(), database.DeleteLikeParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't unlike chirp", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+	"github.com/nurmuh-alhakim18/chirpy/internal/media"
+)
+
+const (
+	maxUploadSize       = 5 << 20
+	maxAttachmentsCount = 4
+)
+
+// --- UPLOAD MEDIA ---
+func (cfg *apiConfig) handlerUploadMedia(w http.ResponseWriter, r *http.Request) {
+	userId := auth.UserIdFromContext(r.Context())
+
+	// Leave some room for the multipart headers around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, maxUploadSize+64<<10)
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respError(w, 413, "File is too large", err)
+			return
+		}
+
+		respError(w, 400, "Couldn't read file", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxUploadSize+1))
+	if err != nil {
+		respError(w, 400, "Couldn't read file", err)
+		return
+	}
+
+	if len(data) > maxUploadSize {
+		respError(w, 413, "File is too large", nil)
+		return
+	}
+
+	img, err := media.Process(data)
+	if err != nil {
+		if errors.Is(err, media.ErrUnsupportedType) {
+			respError(w, 415, "File must be a PNG, JPEG, GIF or WebP image", err)
+			return
+		}
+		if errors.Is(err, media.ErrTooManyPixels) {
+			respError(w, 413, "Image dimensions are too large", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't process image", err)
+		return
+	}
+
+	attachmentId := uuid.New()
+	storageKey := attachmentId.String() + img.Extension
+	thumbnailKey := attachmentId.String() + "_thumb.jpg"
+
+	err = cfg.storage.Put(r.Context(), storageKey, bytes.NewReader(img.Data))
+	if err != nil {
+		respError(w, 500, "Couldn't store file", err)
+		return
+	}
+
+	err = cfg.storage.Put(r.Context(), thumbnailKey, bytes.NewReader(img.Thumbnail))
+	if err != nil {
+		cfg.deleteBlobs(r.Context(), storageKey)
+		respError(w, 500, "Couldn't store thumbnail", err)
+		return
+	}
+
+	attachmentDB, err := cfg.db.CreateAttachment(r.Context(), database.CreateAttachmentParams{
+		ID:           attachmentId,
+		UserID:       userId,
+		ContentType:  img.ContentType,
+		SizeBytes:    int32(len(img.Data)),
+		Width:        int32(img.Width),
+		Height:       int32(img.Height),
+		StorageKey:   storageKey,
+		ThumbnailKey: thumbnailKey,
+	})
+
+	if err != nil {
+		cfg.deleteBlobs(r.Context(), storageKey, thumbnailKey)
+		respError(w, 500, "Couldn't save attachment", err)
+		return
+	}
+
+	respJSON(w, 201, cfg.attachmentFromDB(attachmentDB))
+}
+
+// deleteBlobs removes files from storage. Failures are only logged since the
+// rows pointing at them are already gone.
+func (cfg *apiConfig) deleteBlobs(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		err := cfg.storage.Delete(ctx, key)
+		if err != nil {
+			log.Printf("Error deleting %s from storage: %v", key, err)
+		}
+	}
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// outranks reports whether actorId's role is above targetId's. Moderators
+// can only act against users below them, so they can't lock each other or
+// admins out.
+func (cfg *apiConfig) outranks(ctx context.Context, actorId, targetId uuid.UUID) (bool, error) {
+	actorDB, err := cfg.db.GetUserById(ctx, actorId)
+	if err != nil {
+		return false, err
+	}
+
+	targetDB, err := cfg.db.GetUserById(ctx, targetId)
+	if err != nil {
+		return false, err
+	}
+
+	return !auth.Role(targetDB.Role).Includes(auth.Role(actorDB.Role)), nil
+}
+
+// moderationParameters is the body of a request to hide a chirp or suspend
+// a user. ReportId optionally links the action to the report that led to it.
+type moderationParameters struct {
+	ReportId uuid.NullUUID `json:"report_id"`
+	Note     string        `json:"note"`
+}
+
+// decodeModeration reads moderationParameters, responding with an error and
+// returning false if they're invalid.
+func (cfg *apiConfig) decodeModeration(w http.ResponseWriter, r *http.Request) (moderationParameters, bool) {
+	var params moderationParameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return moderationParameters{}, false
+	}
+
+	if params.ReportId.Valid {
+		_, err := cfg.db.GetReportById(r.Context(), params.ReportId.UUID)
+		if err != nil {
+			respError(w, 404, "Couldn't find report", err)
+			return moderationParameters{}, false
+		}
+	}
+
+	return params, true
+}
+
+// --- GET REPORTS ---
+func (cfg *apiConfig) handlerGetReports(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Reports    []report `json:"reports"`
+		NextCursor string   `json:"next_cursor,omitempty"`
+	}
+
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = reportStatusOpen
+	}
+
+	if status != reportStatusOpen && status != reportStatusResolved {
+		respError(w, 400, "status must be open or resolved", nil)
+		return
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	// Oldest first, so the queue is worked through in order.
+	reportsDB, err := cfg.db.GetReports(r.Context(), database.GetReportsParams{
+		Status:          status,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get reports", err)
+		return
+	}
+
+	var nextCursor string
+	if len(reportsDB) > p.limit {
+		reportsDB = reportsDB[:p.limit]
+		last := reportsDB[len(reportsDB)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	reports := make([]report, 0, len(reportsDB))
+	for _, reportDB := range reportsDB {
+		reports = append(reports, reportFromDB(reportDB))
+	}
+
+	respJSON(w, 200, response{
+		Reports:    reports,
+		NextCursor: nextCursor,
+	})
+}
+
+// --- RESOLVE REPORT ---
+func (cfg *apiConfig) handlerResolveReport(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Resolution string `json:"resolution"`
+		Note       string `json:"note"`
+	}
+
+	reportId, err := uuid.Parse(r.PathValue("reportId"))
+	if err != nil {
+		respError(w, 400, "Invalid report ID", err)
+		return
+	}
+
+	actorId := auth.UserIdFromContext(r.Context())
+
+	var params parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.Resolution != reportResolutionActioned && params.Resolution != reportResolutionDismissed {
+		respError(w, 400, "Resolution must be actioned or dismissed", nil)
+		return
+	}
+
+	var resolved database.Report
+	err = cfg.moderate(r.Context(), actorId, database.CreateModerationActionParams{
+		Action:   moderationResolveReport,
+		ReportID: uuid.NullUUID{UUID: reportId, Valid: true},
+		Note:     params.Note,
+	}, func(q *database.Queries) error {
+		var err error
+		resolved, err = q.ResolveReport(r.Context(), database.ResolveReportParams{
+			ID:         reportId,
+			Resolution: sql.NullString{String: params.Resolution, Valid: true},
+		})
+		return err
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			_, err = cfg.db.GetReportById(r.Context(), reportId)
+			if err != nil {
+				respError(w, 404, "Couldn't find report", err)
+				return
+			}
+
+			respError(w, 409, "Report is already resolved", nil)
+			return
+		}
+
+		respError(w, 500, "Couldn't resolve report", err)
+		return
+	}
+
+	respJSON(w, 200, reportFromDB(resolved))
+}
+
+// --- GET CHIRP FOR REVIEW ---
+func (cfg *apiConfig) handlerGetChirpForReview(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	chirpDB, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't find chirp", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't get chirp", err)
+		return
+	}
+
+	// Unlike everywhere else, a hidden chirp is shown as it was written, so a
+	// reported or hidden chirp can be reviewed before acting on it.
+	chirps, err := cfg.buildChirpsForReview(r.Context(), []database.Chirp{chirpDB})
+	if err != nil {
+		respError(w, 500, "Couldn't get chirp", err)
+		return
+	}
+
+	respJSON(w, 200, chirps[0])
+}
+
+// setChirpHidden hides or unhides the chirp in the request path.
+func (cfg *apiConfig) setChirpHidden(w http.ResponseWriter, r *http.Request, hidden bool) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	actorId := auth.UserIdFromContext(r.Context())
+
+	params, ok := cfg.decodeModeration(w, r)
+	if !ok {
+		return
+	}
+
+	chirpDB, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	allowed, err := cfg.outranks(r.Context(), actorId, chirpDB.UserID)
+	if err != nil {
+		respError(w, 500, "Couldn't update chirp", err)
+		return
+	}
+
+	if !allowed {
+		respError(w, 403, "Couldn't moderate a user with the same or a higher role", nil)
+		return
+	}
+
+	action := moderationUnhideChirp
+	if hidden {
+		action = moderationHideChirp
+	}
+
+	err = cfg.moderate(r.Context(), actorId, database.CreateModerationActionParams{
+		Action:   action,
+		ReportID: params.ReportId,
+		ChirpID:  uuid.NullUUID{UUID: chirpId, Valid: true},
+		UserID:   uuid.NullUUID{UUID: chirpDB.UserID, Valid: true},
+		Note:     params.Note,
+	}, func(q *database.Queries) error {
+		_, err := q.SetChirpHidden(r.Context(), database.SetChirpHiddenParams{
+			ChirpID: chirpId,
+			Hidden:  hidden,
+		})
+		return err
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't update chirp", err)
+		return
+	}
+
+	// Realtime clients drop a hidden chirp the same way as a deleted one.
+	if hidden {
+		cfg.broker.publish(event{
+			Kind:     eventChirpDeleted,
+			UserId:   chirpDB.UserID,
+			ChirpId:  chirpId,
+			ParentId: chirpDB.ParentID.UUID,
+		}, deletedChirp{
+			Id:     chirpId,
+			UserId: chirpDB.UserID,
+		})
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- HIDE CHIRP ---
+func (cfg *apiConfig) handlerHideChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpHidden(w, r, true)
+}
+
+// --- UNHIDE CHIRP ---
+func (cfg *apiConfig) handlerUnhideChirp(w http.ResponseWriter, r *http.Request) {
+	cfg.setChirpHidden(w, r, false)
+}
+
+// setUserSuspended suspends or reinstates the user in the request path.
+// Suspended users can't sign in, refresh their session or post.
+func (cfg *apiConfig) setUserSuspended(w http.ResponseWriter, r *http.Request, suspended bool) {
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respError(w, 400, "Invalid user ID", err)
+		return
+	}
+
+	actorId := auth.UserIdFromContext(r.Context())
+
+	params, ok := cfg.decodeModeration(w, r)
+	if !ok {
+		return
+	}
+
+	allowed, err := cfg.outranks(r.Context(), actorId, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't find user", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't update user", err)
+		return
+	}
+
+	if !allowed {
+		respError(w, 403, "Couldn't moderate a user with the same or a higher role", nil)
+		return
+	}
+
+	action := moderationUnsuspendUser
+	if suspended {
+		action = moderationSuspendUser
+	}
+
+	err = cfg.moderate(r.Context(), actorId, database.CreateModerationActionParams{
+		Action:   action,
+		ReportID: params.ReportId,
+		UserID:   uuid.NullUUID{UUID: userId, Valid: true},
+		Note:     params.Note,
+	}, func(q *database.Queries) error {
+		updated, err := q.SetUserSuspended(r.Context(), database.SetUserSuspendedParams{
+			UserID:    userId,
+			Suspended: suspended,
+		})
+		if err != nil {
+			return err
+		}
+
+		if updated == 0 {
+			return sql.ErrNoRows
+		}
+
+		if !suspended {
+			return nil
+		}
+
+		return q.RevokeUserRefreshTokens(r.Context(), userId)
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't find user", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't update user", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- SUSPEND USER ---
+func (cfg *apiConfig) handlerSuspendUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setUserSuspended(w, r, true)
+}
+
+// --- UNSUSPEND USER ---
+func (cfg *apiConfig) handlerUnsuspendUser(w http.ResponseWriter, r *http.Request) {
+	cfg.setUserSuspended(w, r, false)
+}
+
+// --- GET MODERATION LOG ---
+func (cfg *apiConfig) handlerGetModerationLog(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Actions    []moderationAction `json:"actions"`
+		NextCursor string             `json:"next_cursor,omitempty"`
+	}
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	actionsDB, err := cfg.db.GetModerationActions(r.Context(), database.GetModerationActionsParams{
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get moderation log", err)
+		return
+	}
+
+	var nextCursor string
+	if len(actionsDB) > p.limit {
+		actionsDB = actionsDB[:p.limit]
+		last := actionsDB[len(actionsDB)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	actions := make([]moderationAction, 0, len(actionsDB))
+	for _, actionDB := range actionsDB {
+		actions = append(actions, moderationActionFromDB(actionDB))
+	}
+
+	respJSON(w, 200, response{
+		Actions:    actions,
+		NextCursor: nextCursor,
+	})
+}
+
+// --- SET USER ROLE ---
+func (cfg *apiConfig) handlerSetUserRole(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Role auth.Role `json:"role"`
+		Note string    `json:"note"`
+	}
+
+	userId, err := uuid.Parse(r.PathValue("userId"))
+	if err != nil {
+		respError(w, 400, "Invalid user ID", err)
+		return
+	}
+
+	actorId := auth.UserIdFromContext(r.Context())
+
+	var params parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	if !params.Role.Valid() {
+		respError(w, 400, "Role must be user, moderator or admin", nil)
+		return
+	}
+
+	if userId == actorId {
+		respError(w, 400, "Couldn't change your own role", nil)
+		return
+	}
+
+	// Like other moderation actions, this only reaches users below the actor,
+	// so no admin can demote another and there's always at least one left.
+	allowed, err := cfg.outranks(r.Context(), actorId, userId)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't find user", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't update user", err)
+		return
+	}
+
+	if !allowed {
+		respError(w, 403, "Couldn't moderate a user with the same or a higher role", nil)
+		return
+	}
+
+	err = cfg.moderate(r.Context(), actorId, database.CreateModerationActionParams{
+		Action: moderationSetRole,
+		UserID: uuid.NullUUID{UUID: userId, Valid: true},
+		Note:   params.Note,
+	}, func(q *database.Queries) error {
+		updated, err := q.SetUserRole(r.Context(), database.SetUserRoleParams{
+			ID:   userId,
+			Role: string(params.Role),
+		})
+		if err != nil {
+			return err
+		}
+
+		if updated == 0 {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't find user", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't update user", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+type notification struct {
+	Id            uuid.UUID  `json:"id"`
+	Kind          string     `json:"kind"`
+	ActorId       uuid.UUID  `json:"actor_id"`
+	ActorUsername string     `json:"actor_username,omitempty"`
+	ChirpId       *uuid.UUID `json:"chirp_id,omitempty"`
+	Read          bool       `json:"read"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+func notificationFromDB(notificationDB database.Notification, actorUsername string) notification {
+	n := notification{
+		Id:            notificationDB.ID,
+		Kind:          notificationDB.Kind,
+		ActorId:       notificationDB.ActorID,
+		ActorUsername: actorUsername,
+		Read:          notificationDB.ReadAt.Valid,
+		CreatedAt:     notificationDB.CreatedAt,
+	}
+
+	if notificationDB.ChirpID.Valid {
+		chirpId := notificationDB.ChirpID.UUID
+		n.ChirpId = &chirpId
+	}
+
+	return n
+}
+
+// --- GET NOTIFICATIONS ---
+func (cfg *apiConfig) handlerGetNotifications(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Notifications []notification `json:"notifications"`
+		UnreadCount   int64          `json:"unread_count"`
+		NextCursor    string         `json:"next_cursor,omitempty"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	notificationsDB, err := cfg.db.GetNotifications(r.Context(), database.GetNotificationsParams{
+		UserID:          userId,
+		UnreadOnly:      r.URL.Query().Get("unread") == "true",
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get notifications", err)
+		return
+	}
+
+	unreadCount, err := cfg.db.GetUnreadNotificationCount(r.Context(), userId)
+	if err != nil {
+		respError(w, 500, "Couldn't get notifications", err)
+		return
+	}
+
+	var nextCursor string
+	if len(notificationsDB) > p.limit {
+		notificationsDB = notificationsDB[:p.limit]
+		last := notificationsDB[len(notificationsDB)-1].Notification
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	notifications := make([]notification, 0, len(notificationsDB))
+	for _, notificationDB := range notificationsDB {
+		notifications = append(notifications, notificationFromDB(notificationDB.Notification, notificationDB.ActorUsername.String))
+	}
+
+	respJSON(w, 200, response{
+		Notifications: notifications,
+		UnreadCount:   unreadCount,
+		NextCursor:    nextCursor,
+	})
+}
+
+// --- MARK NOTIFICATIONS READ ---
+func (cfg *apiConfig) handlerMarkNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Ids []uuid.UUID `json:"ids"`
+		All bool        `json:"all"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	var params parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	var err error
+	if params.All {
+		err = cfg.db.MarkAllNotificationsRead(r.Context(), userId)
+	} else if len(params.Ids) > 0 {
+		err = cfg.db.MarkNotificationsRead(r.Context(), database.MarkNotificationsReadParams{
+			UserID: userId,
+			Ids:    params.Ids,
+		})
+	} else {
+		respError(w, 400, "Either ids or all must be set", nil)
+		return
+	}
+
+	if err != nil {
+		respError(w, 500, "Couldn't mark notifications read", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
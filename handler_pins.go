@@ -0,0 +1,89 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// --- PIN CHIRP ---
+func (cfg *apiConfig) handlerPinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	chirpDB, err := cfg.db.GetChirpById(r.Context(), chirpId)
+	if err != nil || chirpDB.IsTombstone {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	if chirpDB.UserID != userId {
+		respError(w, 403, "Can only pin your own chirps", nil)
+		return
+	}
+
+	pinnedDB, err := cfg.db.GetPinnedChirps(r.Context(), database.GetPinnedChirpsParams{
+		UserID:   userId,
+		ViewerID: userId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't pin chirp", err)
+		return
+	}
+
+	for _, pinned := range pinnedDB {
+		if pinned.ID == chirpId {
+			w.WriteHeader(204)
+			return
+		}
+	}
+
+	pinned, err := cfg.db.PinChirp(r.Context(), database.PinChirpParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+
+	if err != nil && !isUniqueViolation(err) {
+		respError(w, 500, "Couldn't pin chirp", err)
+		return
+	}
+
+	// Either every slot was taken or another request took the last one.
+	if pinned == 0 {
+		respError(w, 409, "Can't pin more than 3 chirps", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
+
+// --- UNPIN CHIRP ---
+func (cfg *apiConfig) handlerUnpinChirp(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	err = cfg.db.UnpinChirp(r.Context(), database.UnpinChirpParams{
+		UserID:  userId,
+		ChirpID: chirpId,
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't unpin chirp", err)
+		return
+	}
+
+	w.WriteHeader(204)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// --- VOTE IN POLL ---
+func (cfg *apiConfig) handlerVotePoll(w http.ResponseWriter, r *http.Request) {
+	type parameters struct {
+		Option *int `json:"option"`
+	}
+
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	var params parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	if params.Option == nil {
+		respError(w, 400, "Option is required", nil)
+		return
+	}
+
+	_, err = cfg.db.GetVisibleChirpById(r.Context(), database.GetVisibleChirpByIdParams{
+		ChirpID:  chirpId,
+		ViewerID: userId,
+	})
+
+	if err != nil {
+		respError(w, 404, "Couldn't find poll", err)
+		return
+	}
+
+	polls, err := cfg.buildPolls(r.Context(), userId, []uuid.UUID{chirpId})
+	if err != nil {
+		respError(w, 500, "Couldn't get poll", err)
+		return
+	}
+
+	p, ok := polls[chirpId]
+	if !ok {
+		respError(w, 404, "Couldn't find poll", nil)
+		return
+	}
+
+	if p.Closed {
+		respError(w, 409, "Poll is closed", nil)
+		return
+	}
+
+	if p.VotedOption != nil {
+		respError(w, 409, "Already voted in this poll", nil)
+		return
+	}
+
+	if *params.Option < 0 || *params.Option >= len(p.Options) {
+		respError(w, 400, "Invalid option", nil)
+		return
+	}
+
+	// The checks above make for clearer errors, but the database is what
+	// enforces one vote per user and no votes after the poll closes.
+	voted, err := cfg.db.CreatePollVote(r.Context(), database.CreatePollVoteParams{
+		ChirpID:  chirpId,
+		UserID:   userId,
+		Position: int32(*params.Option),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't vote", err)
+		return
+	}
+
+	if voted == 0 {
+		respError(w, 409, "Already voted or poll is closed", nil)
+		return
+	}
+
+	polls, err = cfg.buildPolls(r.Context(), userId, []uuid.UUID{chirpId})
+	if err != nil {
+		respError(w, 500, "Couldn't get poll", err)
+		return
+	}
+
+	respJSON(w, 200, polls[chirpId])
+}
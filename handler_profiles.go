@@ -0,0 +1,179 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/chirptext"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+)
+
+// profile is what anyone can see about a user. Like publicUser, it never
+// includes the email address.
+type profile struct {
+	publicUser
+	Bio            string `json:"bio"`
+	FollowerCount  int    `json:"follower_count"`
+	FollowingCount int    `json:"following_count"`
+	ChirpCount     int    `json:"chirp_count"`
+}
+
+// --- GET USER PROFILE ---
+func (cfg *apiConfig) handlerGetUserProfile(w http.ResponseWriter, r *http.Request) {
+	profileDB, err := cfg.db.GetUserProfile(r.Context(), r.PathValue("username"))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			respError(w, 404, "Couldn't find user", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't get user", err)
+		return
+	}
+
+	respJSON(w, 200, profile{
+		publicUser: publicUser{
+			Id:          profileDB.User.ID,
+			Username:    profileDB.User.Username.String,
+			DisplayName: profileDB.User.DisplayName,
+			AvatarURL:   cfg.avatarKeyURL(profileDB.AvatarKey),
+			IsChirpyRed: profileDB.User.IsChirpyRed,
+			CreatedAt:   profileDB.User.CreatedAt,
+		},
+		Bio:            profileDB.User.Bio,
+		FollowerCount:  int(profileDB.FollowerCount),
+		FollowingCount: int(profileDB.FollowingCount),
+		ChirpCount:     int(profileDB.ChirpCount),
+	})
+}
+
+// --- UPDATE PROFILE ---
+func (cfg *apiConfig) handlerUpdateProfile(w http.ResponseWriter, r *http.Request) {
+	// Every field is optional and only the ones present are changed. An
+	// empty avatar_id removes the avatar.
+	type parameters struct {
+		Username    *string `json:"username"`
+		DisplayName *string `json:"display_name"`
+		Bio         *string `json:"bio"`
+		AvatarId    *string `json:"avatar_id"`
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	var params parameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	userDB, err := cfg.db.GetUserById(r.Context(), userId)
+	if err != nil {
+		respError(w, 404, "Couldn't find user", err)
+		return
+	}
+
+	updateParams := database.UpdateUserProfileParams{
+		ID:          userDB.ID,
+		Username:    userDB.Username,
+		DisplayName: userDB.DisplayName,
+		Bio:         userDB.Bio,
+		AvatarID:    userDB.AvatarID,
+	}
+
+	if params.Username != nil {
+		if !chirptext.ValidUsername(*params.Username) {
+			respError(w, 400, "Username must be 3-20 letters, digits or underscores", nil)
+			return
+		}
+		updateParams.Username = sql.NullString{String: *params.Username, Valid: true}
+	}
+
+	if params.DisplayName != nil {
+		displayName := strings.TrimSpace(*params.DisplayName)
+		if utf8.RuneCountInString(displayName) > maxDisplayNameLength {
+			respError(w, 400, fmt.Sprintf("Display name must be at most %d characters", maxDisplayNameLength), nil)
+			return
+		}
+		updateParams.DisplayName = displayName
+	}
+
+	if params.Bio != nil {
+		bio := strings.TrimSpace(*params.Bio)
+		if utf8.RuneCountInString(bio) > maxBioLength {
+			respError(w, 400, fmt.Sprintf("Bio must be at most %d characters", maxBioLength), nil)
+			return
+		}
+		updateParams.Bio = bio
+	}
+
+	if params.AvatarId != nil {
+		updateParams.AvatarID = uuid.NullUUID{}
+		if *params.AvatarId != "" {
+			avatarId, err := uuid.Parse(*params.AvatarId)
+			if err != nil {
+				respError(w, 400, "Invalid avatar ID", err)
+				return
+			}
+			updateParams.AvatarID = uuid.NullUUID{UUID: avatarId, Valid: true}
+		}
+	}
+
+	avatarChanged := updateParams.AvatarID != userDB.AvatarID
+	if avatarChanged && updateParams.AvatarID.Valid {
+		// The avatar must be an upload of the user's that isn't on a chirp.
+		count, err := cfg.db.CountAttachable(r.Context(), database.CountAttachableParams{
+			AttachmentIds: []uuid.UUID{updateParams.AvatarID.UUID},
+			UserID:        userId,
+		})
+
+		if err != nil {
+			respError(w, 500, "Couldn't check avatar", err)
+			return
+		}
+
+		if count != 1 {
+			respError(w, 400, "Avatar must be one of your unused uploads", nil)
+			return
+		}
+	}
+
+	userUpdated, err := cfg.db.UpdateUserProfile(r.Context(), updateParams)
+	if err != nil {
+		if isUniqueViolation(err) {
+			respError(w, 409, "Username already taken", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't update profile", err)
+		return
+	}
+
+	// The old avatar isn't used anywhere else, so it goes with its files.
+	if avatarChanged && userDB.AvatarID.Valid {
+		deletedDB, err := cfg.db.DeleteAttachment(r.Context(), userDB.AvatarID.UUID)
+		if err == nil {
+			cfg.deleteBlobs(r.Context(), deletedDB.StorageKey, deletedDB.ThumbnailKey)
+		}
+	}
+
+	avatarURL, err := cfg.avatarURL(r.Context(), userUpdated.AvatarID)
+	if err != nil {
+		respError(w, 500, "Couldn't get avatar", err)
+		return
+	}
+
+	respJSON(w, 200, userFromDB(userUpdated, avatarURL))
+}
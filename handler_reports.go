@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// reportParameters is the body of a report request.
+type reportParameters struct {
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// createReport files a report from reporterId, responding with it or an
+// error.
+func (cfg *apiConfig) createReport(w http.ResponseWriter, r *http.Request, reporterId uuid.UUID, userId uuid.UUID, chirpId uuid.NullUUID) {
+	var params reportParameters
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&params); err != nil {
+		respError(w, 500, "Couldn't decode parameters", err)
+		return
+	}
+
+	if _, ok := reportReasons[params.Reason]; !ok {
+		respError(w, 400, "Invalid report reason", nil)
+		return
+	}
+
+	details := strings.TrimSpace(params.Details)
+	if utf8.RuneCountInString(details) > maxReportDetailsLength {
+		respError(w, 400, fmt.Sprintf("Details must be at most %d characters", maxReportDetailsLength), nil)
+		return
+	}
+
+	reportDB, err := cfg.db.CreateReport(r.Context(), database.CreateReportParams{
+		ReporterID: reporterId,
+		UserID:     userId,
+		ChirpID:    chirpId,
+		Reason:     params.Reason,
+		Details:    details,
+	})
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			respError(w, 409, "Already reported", err)
+			return
+		}
+
+		respError(w, 500, "Couldn't create report", err)
+		return
+	}
+
+	respJSON(w, 201, reportFromDB(reportDB))
+}
+
+// --- REPORT CHIRP ---
+func (cfg *apiConfig) handlerReportChirp(w http.ResponseWriter, r *http.Request) {
+	chirpId, err := uuid.Parse(r.PathValue("chirpId"))
+	if err != nil {
+		respError(w, 400, "Invalid chirp ID", err)
+		return
+	}
+
+	userId := auth.UserIdFromContext(r.Context())
+
+	// A rechirp has nothing of its own to report, so the report is about
+	// the original.
+	chirpDB, err := cfg.resolveOriginalChirp(r.Context(), userId, chirpId)
+	if err != nil {
+		respError(w, 404, "Couldn't get chirp", err)
+		return
+	}
+
+	if chirpDB.UserID == userId {
+		respError(w, 400, "Couldn't report your own chirp", nil)
+		return
+	}
+
+	cfg.createReport(w, r, userId, chirpDB.UserID, uuid.NullUUID{UUID: chirpDB.ID, Valid: true})
+}
+
+// --- REPORT USER ---
+func (cfg *apiConfig) handlerReportUser(w http.ResponseWriter, r *http.Request) {
+	userId, reportedId, ok := cfg.authTargetUser(w, r, "report")
+	if !ok {
+		return
+	}
+
+	cfg.createReport(w, r, userId, reportedId, uuid.NullUUID{})
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// --- SEARCH CHIRPS ---
+func (cfg *apiConfig) handlerSearchChirps(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []chirp `json:"chirps"`
+		NextOffset int     `json:"next_offset,omitempty"`
+	}
+
+	query := r.URL.Query()
+
+	viewerId := auth.UserIdFromContext(r.Context())
+
+	// q follows web search syntax: "quoted phrases", OR and -excluded words.
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		respError(w, 400, "Search query is required", nil)
+		return
+	}
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	// Results are ordered by relevance, which isn't stable enough for a
+	// keyset cursor, so search pages by offset instead.
+	offset := 0
+	offsetString := query.Get("offset")
+	if offsetString != "" {
+		offset, err = strconv.Atoi(offsetString)
+		if err != nil || offset < 0 {
+			respError(w, 400, "offset must be a non-negative integer", err)
+			return
+		}
+	}
+
+	var authorId uuid.NullUUID
+	authorIdString := query.Get("author_id")
+	if authorIdString != "" {
+		authorId.UUID, err = uuid.Parse(authorIdString)
+		if err != nil {
+			respError(w, 400, "Couldn't parse author id", err)
+			return
+		}
+		authorId.Valid = true
+	}
+
+	resultsDB, err := cfg.db.SearchChirps(r.Context(), database.SearchChirpsParams{
+		Query:    q,
+		AuthorID: authorId,
+		ViewerID: viewerId,
+		Offset:   int32(offset),
+		Limit:    int32(limit + 1),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't search chirps", err)
+		return
+	}
+
+	var nextOffset int
+	if len(resultsDB) > limit {
+		resultsDB = resultsDB[:limit]
+		nextOffset = offset + limit
+	}
+
+	chirpsDB := make([]database.Chirp, 0, len(resultsDB))
+	for _, resultDB := range resultsDB {
+		chirpsDB = append(chirpsDB, resultDB.Chirp)
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), viewerId, chirpsDB)
+	if err != nil {
+		respError(w, 500, "Couldn't search chirps", err)
+		return
+	}
+
+	for i, resultDB := range resultsDB {
+		chirps[i].Snippet = highlightSnippet(resultDB.Snippet)
+	}
+
+	respJSON(w, 200, response{
+		Chirps:     chirps,
+		NextOffset: nextOffset,
+	})
+}
+
+// highlightSnippet escapes a ts_headline fragment and swaps the control
+// characters the query uses as match delimiters for <mark> tags, so a chirp
+// body can't inject markup of its own.
+func highlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	escaped = strings.ReplaceAll(escaped, "\x01", "<mark>")
+	return strings.ReplaceAll(escaped, "\x02", "</mark>")
+}
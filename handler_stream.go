@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+)
+
+const streamHeartbeatInterval = 15 * time.Second
+
+// --- STREAM CHIRPS ---
+func (cfg *apiConfig) handlerStreamChirps(w http.ResponseWriter, r *http.Request) {
+	var authorId uuid.NullUUID
+	authorIdString := r.URL.Query().Get("author_id")
+	if authorIdString != "" {
+		var err error
+		authorId.UUID, err = uuid.Parse(authorIdString)
+		if err != nil {
+			respError(w, 400, "Couldn't parse author id", err)
+			return
+		}
+		authorId.Valid = true
+	}
+
+	// Browsers send Last-Event-ID when EventSource reconnects on its own;
+	// the query parameter lets clients resume after a page reload too.
+	var lastEventId int64
+	lastEventIdString := r.Header.Get("Last-Event-ID")
+	if lastEventIdString == "" {
+		lastEventIdString = r.URL.Query().Get("last_event_id")
+	}
+	if lastEventIdString != "" {
+		var err error
+		lastEventId, err = strconv.ParseInt(lastEventIdString, 10, 64)
+		if err != nil {
+			respError(w, 400, "Invalid last event ID", err)
+			return
+		}
+	}
+
+	events, missed, unsubscribe := cfg.broker.subscribe(lastEventId)
+	defer unsubscribe()
+
+	// Signed-in viewers don't get chirps their feeds would leave out.
+	viewerId := auth.UserIdFromContext(r.Context())
+	aud, err := cfg.loadAudience(viewerId)
+	if err != nil {
+		respError(w, 500, "Couldn't open stream", err)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(200)
+	fmt.Fprint(w, "retry: 3000\n\n")
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	send := func(e event) error {
+		if e.Kind == eventRelationsChanged && viewerId != uuid.Nil && e.UserId == viewerId {
+			aud, err = cfg.loadAudience(viewerId)
+			return err
+		}
+
+		if e.Kind != eventChirpCreated && e.Kind != eventChirpDeleted {
+			return nil
+		}
+
+		if authorId.Valid && e.UserId != authorId.UUID {
+			return nil
+		}
+
+		if aud.blocks(e) || aud.mutes(e) {
+			return nil
+		}
+
+		fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.Id, e.Kind, e.Data)
+		return rc.Flush()
+	}
+
+	for _, e := range missed {
+		if err := send(e); err != nil {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(streamHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			if err := send(e); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
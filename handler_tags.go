@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+// --- GET CHIRPS BY TAG ---
+func (cfg *apiConfig) handlerGetChirpsByTag(w http.ResponseWriter, r *http.Request) {
+	type response struct {
+		Chirps     []chirp `json:"chirps"`
+		NextCursor string  `json:"next_cursor,omitempty"`
+	}
+
+	tag := strings.ToLower(strings.TrimPrefix(r.PathValue("tag"), "#"))
+	if tag == "" {
+		respError(w, 400, "Invalid tag", nil)
+		return
+	}
+
+	viewerId := auth.UserIdFromContext(r.Context())
+
+	p, err := parsePage(r)
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	chirpsDB, err := cfg.db.GetChirpsByTag(r.Context(), database.GetChirpsByTagParams{
+		Tag:             tag,
+		ViewerID:        viewerId,
+		CursorCreatedAt: p.cursorCreatedAt,
+		CursorID:        p.cursorId,
+		Limit:           p.fetchLimit(),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get chirps", err)
+		return
+	}
+
+	var nextCursor string
+	if len(chirpsDB) > p.limit {
+		chirpsDB = chirpsDB[:p.limit]
+		last := chirpsDB[len(chirpsDB)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	chirps, err := cfg.buildChirps(r.Context(), viewerId, chirpsDB)
+	if err != nil {
+		respError(w, 500, "Couldn't get chirps", err)
+		return
+	}
+
+	respJSON(w, 200, response{
+		Chirps:     chirps,
+		NextCursor: nextCursor,
+	})
+}
+
+// --- GET TRENDING TAGS ---
+const (
+	defaultTrendingWindow = 24 * time.Hour
+	maxTrendingWindow     = 7 * 24 * time.Hour
+)
+
+func (cfg *apiConfig) handlerGetTrendingTags(w http.ResponseWriter, r *http.Request) {
+	type trendingTag struct {
+		Tag           string `json:"tag"`
+		Count         int64  `json:"count"`
+		PreviousCount int64  `json:"previous_count"`
+		Velocity      int64  `json:"velocity"`
+	}
+
+	query := r.URL.Query()
+
+	limit, err := parseLimit(query.Get("limit"))
+	if err != nil {
+		respError(w, 400, err.Error(), err)
+		return
+	}
+
+	// window is the size of the sliding window in hours.
+	window := defaultTrendingWindow
+	windowString := query.Get("window")
+	if windowString != "" {
+		hours, err := strconv.Atoi(windowString)
+		if err != nil || hours < 1 || time.Duration(hours)*time.Hour > maxTrendingWindow {
+			respError(w, 400, "window must be between 1 and 168 hours", err)
+			return
+		}
+		window = time.Duration(hours) * time.Hour
+	}
+
+	tagsDB, err := cfg.db.GetTrendingTags(r.Context(), database.GetTrendingTagsParams{
+		WindowSeconds: window.Seconds(),
+		Limit:         int32(limit),
+	})
+
+	if err != nil {
+		respError(w, 500, "Couldn't get trending tags", err)
+		return
+	}
+
+	tags := make([]trendingTag, 0, len(tagsDB))
+	for _, tagDB := range tagsDB {
+		tags = append(tags, trendingTag{
+			Tag:           tagDB.Tag,
+			Count:         tagDB.RecentCount,
+			PreviousCount: tagDB.PreviousCount,
+			Velocity:      tagDB.RecentCount - tagDB.PreviousCount,
+		})
+	}
+
+	respJSON(w, 200, tags)
+}
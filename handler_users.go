@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
@@ -8,23 +9,87 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/chirptext"
 	"github.com/nurmuh-alhakim18/chirpy/internal/database"
 )
 
 type user struct {
 	Id          uuid.UUID `json:"id"`
 	Email       string    `json:"email"`
+	Username    string    `json:"username,omitempty"`
+	DisplayName string    `json:"display_name"`
+	Bio         string    `json:"bio"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
 	Password    string    `json:"password,omitempty"`
 	IsChirpyRed bool      `json:"is_chirpy_red"`
+	Role        string    `json:"role"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// userFromDB builds the private view of a user's own account. avatarURL is
+// loaded separately since it lives with the avatar's attachment.
+func userFromDB(userDB database.User, avatarURL string) user {
+	return user{
+		Id:          userDB.ID,
+		Email:       userDB.Email,
+		Username:    userDB.Username.String,
+		DisplayName: userDB.DisplayName,
+		Bio:         userDB.Bio,
+		AvatarURL:   avatarURL,
+		IsChirpyRed: userDB.IsChirpyRed,
+		Role:        userDB.Role,
+		CreatedAt:   userDB.CreatedAt,
+		UpdatedAt:   userDB.UpdatedAt,
+	}
+}
+
+// publicUser is the shape used when listing users to other users, so it
+// never includes the email address.
+type publicUser struct {
+	Id          uuid.UUID `json:"id"`
+	Username    string    `json:"username,omitempty"`
+	DisplayName string    `json:"display_name,omitempty"`
+	AvatarURL   string    `json:"avatar_url,omitempty"`
+	IsChirpyRed bool      `json:"is_chirpy_red"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// avatarURL returns the URL of the user's avatar, or "" if they haven't set
+// one. Avatars are shown at thumbnail size.
+func (cfg *apiConfig) avatarURL(ctx context.Context, avatarId uuid.NullUUID) (string, error) {
+	if !avatarId.Valid {
+		return "", nil
+	}
+
+	attachmentDB, err := cfg.db.GetAttachmentById(ctx, avatarId.UUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	return cfg.storage.URL(attachmentDB.ThumbnailKey), nil
+}
+
+// avatarKeyURL is avatarURL for queries that join in the avatar's thumbnail
+// key.
+func (cfg *apiConfig) avatarKeyURL(avatarKey sql.NullString) string {
+	if !avatarKey.Valid {
+		return ""
+	}
+
+	return cfg.storage.URL(avatarKey.String)
+}
+
 func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request) {
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Username string `json:"username"`
 	}
 
 	var params parameters
@@ -34,6 +99,11 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if params.Username != "" && !chirptext.ValidUsername(params.Username) {
+		respError(w, 400, "Username must be 3-20 letters, digits or underscores", nil)
+		return
+	}
+
 	hashedPass, err := auth.HashPassword(params.Password)
 	if err != nil {
 		respError(w, 500, "Couldn't hash password", err)
@@ -43,20 +113,23 @@ func (cfg *apiConfig) handlerCreateUser(w http.ResponseWriter, r *http.Request)
 	userCreated, err := cfg.db.CreateUser(r.Context(), database.CreateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPass,
+		Username: sql.NullString{
+			String: params.Username,
+			Valid:  params.Username != "",
+		},
 	})
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			respError(w, 409, "Email or username already taken", err)
+			return
+		}
+
 		respError(w, 500, "Couldn't create user", err)
 		return
 	}
 
-	respJSON(w, 201, user{
-		Id:          userCreated.ID,
-		Email:       userCreated.Email,
-		IsChirpyRed: userCreated.IsChirpyRed,
-		CreatedAt:   userCreated.CreatedAt,
-		UpdatedAt:   userCreated.UpdatedAt,
-	})
+	respJSON(w, 201, userFromDB(userCreated, ""))
 }
 
 func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
@@ -90,36 +163,31 @@ func (cfg *apiConfig) handlerUserLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	token, err := auth.MakeJWT(userDB.ID, cfg.secretKeyJWT, time.Hour)
+	if userDB.SuspendedAt.Valid {
+		respError(w, 403, "Account is suspended", nil)
+		return
+	}
+
+	token, err := auth.MakeJWT(userDB.ID, auth.Role(userDB.Role), cfg.secretKeyJWT, time.Hour)
 	if err != nil {
 		respError(w, 500, "Couldn't create access JWT", err)
 		return
 	}
 
-	refreshToken, err := auth.MakeRefreshToken()
+	refreshToken, err := createRefreshToken(r.Context(), cfg.db, userDB.ID, uuid.New())
 	if err != nil {
 		respError(w, 500, "Couldn't create refresh token", err)
 		return
 	}
 
-	_, err = cfg.db.CreateRefreshToken(r.Context(), database.CreateRefreshTokenParams{
-		Token:     refreshToken,
-		UserID:    userDB.ID,
-		ExpiresAt: time.Now().UTC().Add(time.Hour * 24 * 60),
-	})
-
+	avatarURL, err := cfg.avatarURL(r.Context(), userDB.AvatarID)
 	if err != nil {
-		respError(w, 500, "Couldn't save refresh token", err)
+		respError(w, 500, "Couldn't get avatar", err)
+		return
 	}
 
 	respJSON(w, 200, response{
-		user: user{
-			Id:          userDB.ID,
-			Email:       userDB.Email,
-			IsChirpyRed: userDB.IsChirpyRed,
-			CreatedAt:   userDB.CreatedAt,
-			UpdatedAt:   userDB.UpdatedAt,
-		},
+		user:         userFromDB(userDB, avatarURL),
 		Token:        token,
 		RefreshToken: refreshToken,
 	})
@@ -129,6 +197,7 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 	type parameters struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
+		Username string `json:"username"`
 	}
 
 	var params parameters
@@ -137,15 +206,10 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		respError(w, 500, "Couldn't decode parameters", err)
 	}
 
-	token, err := auth.GetBearerToken(r.Header)
-	if err != nil {
-		respError(w, 401, "Couldn't find JWT", err)
-		return
-	}
+	userId := auth.UserIdFromContext(r.Context())
 
-	userId, err := auth.ValidateJWT(token, cfg.secretKeyJWT)
-	if err != nil {
-		respError(w, 401, "Couldn't validate JWT", err)
+	if params.Username != "" && !chirptext.ValidUsername(params.Username) {
+		respError(w, 400, "Username must be 3-20 letters, digits or underscores", nil)
 		return
 	}
 
@@ -155,28 +219,43 @@ func (cfg *apiConfig) handlerUpdateUser(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Username is optional here so clients that only change their email and
+	// password keep the username they have.
 	userUpdated, err := cfg.db.UpdateUser(r.Context(), database.UpdateUserParams{
 		Email:          params.Email,
 		HashedPassword: hashedPass,
-		ID:             userId,
+		Username: sql.NullString{
+			String: params.Username,
+			Valid:  params.Username != "",
+		},
+		ID: userId,
 	})
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			respError(w, 409, "Email or username already taken", err)
+			return
+		}
+
 		respError(w, 500, "Couldn't update user data", err)
+		return
 	}
 
-	respJSON(w, 200, user{
-		Id:          userUpdated.ID,
-		Email:       userUpdated.Email,
-		IsChirpyRed: userUpdated.IsChirpyRed,
-		CreatedAt:   userUpdated.CreatedAt,
-		UpdatedAt:   userUpdated.UpdatedAt,
-	})
+	avatarURL, err := cfg.avatarURL(r.Context(), userUpdated.AvatarID)
+	if err != nil {
+		respError(w, 500, "Couldn't get avatar", err)
+		return
+	}
+
+	respJSON(w, 200, userFromDB(userUpdated, avatarURL))
 }
 
+// handlerRefreshToken trades a refresh token for a new access token and a
+// new refresh token. The old refresh token can't be used again.
 func (cfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request) {
 	type response struct {
-		Token string `json:"token"`
+		Token        string `json:"token"`
+		RefreshToken string `json:"refresh_token"`
 	}
 
 	refreshToken, err := auth.GetBearerToken(r.Header)
@@ -185,20 +264,64 @@ func (cfg *apiConfig) handlerRefreshToken(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, err := cfg.db.GetUserFromRefreshToken(r.Context(), refreshToken)
+	tx, err := cfg.dbConn.BeginTx(r.Context(), nil)
+	if err != nil {
+		respError(w, 500, "Couldn't refresh session", err)
+		return
+	}
+	defer tx.Rollback()
+
+	q := cfg.db.WithTx(tx)
+
+	oldToken, err := q.RotateRefreshToken(r.Context(), refreshToken)
 	if err != nil {
-		respError(w, 401, "Couldn't get user for refresh token", err)
+		if !errors.Is(err, sql.ErrNoRows) {
+			respError(w, 500, "Couldn't refresh session", err)
+			return
+		}
+
+		err = cfg.revokeIfReused(r.Context(), refreshToken)
+		if err != nil {
+			respError(w, 500, "Couldn't refresh session", err)
+			return
+		}
+
+		respAuthError(w, 401, auth.ErrInvalidToken, "Couldn't get user for refresh token", nil)
 		return
 	}
 
-	accessToken, err := auth.MakeJWT(user.ID, cfg.secretKeyJWT, time.Hour)
+	user, err := q.GetUserById(r.Context(), oldToken.UserID)
 	if err != nil {
-		respError(w, 401, "Couldn't validate token", err)
+		respError(w, 500, "Couldn't get user for refresh token", err)
+		return
+	}
+
+	if user.SuspendedAt.Valid {
+		respAuthError(w, 401, auth.ErrInvalidToken, "Couldn't get user for refresh token", nil)
+		return
+	}
+
+	newRefreshToken, err := createRefreshToken(r.Context(), q, user.ID, oldToken.FamilyID)
+	if err != nil {
+		respError(w, 500, "Couldn't create refresh token", err)
+		return
+	}
+
+	accessToken, err := auth.MakeJWT(user.ID, auth.Role(user.Role), cfg.secretKeyJWT, time.Hour)
+	if err != nil {
+		respError(w, 500, "Couldn't create access JWT", err)
+		return
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		respError(w, 500, "Couldn't refresh session", err)
 		return
 	}
 
 	respJSON(w, http.StatusOK, response{
-		Token: accessToken,
+		Token:        accessToken,
+		RefreshToken: newRefreshToken,
 	})
 }
 
@@ -209,7 +332,7 @@ func (cfg *apiConfig) handlerRevokeToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	_, err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
+	err = cfg.db.RevokeRefreshToken(r.Context(), refreshToken)
 	if err != nil {
 		respError(w, http.StatusInternalServerError, "Couldn't revoke session", err)
 		return
@@ -261,3 +384,10 @@ func (cfg *apiConfig) handlerUpgradeChirpyRed(w http.ResponseWriter, r *http.Req
 
 	w.WriteHeader(204)
 }
+
+// isUniqueViolation reports whether err came from inserting or updating a row
+// that clashes with a unique constraint.
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
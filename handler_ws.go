@@ -0,0 +1,391 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const (
+	wsWriteWait        = 10 * time.Second
+	wsPongWait         = 60 * time.Second
+	wsPingInterval     = (wsPongWait * 9) / 10
+	wsMaxMessageSize   = 4096
+	wsReplyBufferSize  = 16
+	wsMaxThreadsPerCon = 20
+)
+
+const (
+	wsChannelTimeline      = "timeline"
+	wsChannelNotifications = "notifications"
+	wsChannelThreadPrefix  = "thread:"
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// wsClientMessage is a frame sent by the client, e.g.
+// {"type": "subscribe", "channel": "thread:<chirp id>"}.
+type wsClientMessage struct {
+	Type    string `json:"type"`
+	Channel string `json:"channel"`
+}
+
+// wsServerMessage is a frame sent to the client. Type is "subscribed",
+// "unsubscribed", "event" or "error".
+type wsServerMessage struct {
+	Type    string          `json:"type"`
+	Channel string          `json:"channel,omitempty"`
+	Event   string          `json:"event,omitempty"`
+	Id      int64           `json:"id,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Message string          `json:"message,omitempty"`
+}
+
+// wsClient is one WebSocket connection. The read loop handles subscription
+// requests and the write loop, the only goroutine that writes to conn,
+// delivers their replies along with matching broker events.
+type wsClient struct {
+	cfg     *apiConfig
+	conn    *websocket.Conn
+	userId  uuid.UUID
+	replies chan wsServerMessage
+	done    chan struct{}
+
+	mu sync.Mutex
+	// timeline holds the authors whose chirps make up the user's timeline,
+	// and is nil until they subscribe. It's loaded when subscribing and
+	// reloaded by refresh whenever the user's follows, blocks or mutes
+	// change.
+	timeline map[uuid.UUID]struct{}
+	// threads maps each subscribed thread's root to the chirps known to be
+	// in it, growing as replies arrive.
+	threads       map[uuid.UUID]map[uuid.UUID]struct{}
+	notifications bool
+	audience      *audience
+}
+
+// --- WEBSOCKET ---
+func (cfg *apiConfig) handlerWebSocket(w http.ResponseWriter, r *http.Request) {
+	userId := auth.UserIdFromContext(r.Context())
+
+	// Subscribing before loading the audience means a change in between is
+	// still picked up from the queue.
+	events, _, unsubscribe := cfg.broker.subscribe(0)
+	defer unsubscribe()
+
+	aud, err := cfg.loadAudience(userId)
+	if err != nil {
+		respError(w, 500, "Couldn't open connection", err)
+		return
+	}
+
+	// Upgrade writes its own error response on failure.
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	client := &wsClient{
+		cfg:      cfg,
+		conn:     conn,
+		userId:   userId,
+		replies:  make(chan wsServerMessage, wsReplyBufferSize),
+		done:     make(chan struct{}),
+		threads:  make(map[uuid.UUID]map[uuid.UUID]struct{}),
+		audience: aud,
+	}
+
+	go client.readLoop()
+	client.writeLoop(events)
+}
+
+func (c *wsClient) readLoop() {
+	defer close(c.done)
+
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		var message wsClientMessage
+		err := c.conn.ReadJSON(&message)
+		if err != nil {
+			return
+		}
+
+		switch message.Type {
+		case "subscribe":
+			err = c.subscribe(message.Channel)
+			if err != nil {
+				c.reply(wsServerMessage{Type: "error", Channel: message.Channel, Message: err.Error()})
+				continue
+			}
+			c.reply(wsServerMessage{Type: "subscribed", Channel: message.Channel})
+		case "unsubscribe":
+			c.unsubscribe(message.Channel)
+			c.reply(wsServerMessage{Type: "unsubscribed", Channel: message.Channel})
+		default:
+			c.reply(wsServerMessage{Type: "error", Message: "unknown message type"})
+		}
+	}
+}
+
+// reply queues a message for the write loop. A client that doesn't read its
+// replies is disconnected rather than allowed to block the read loop.
+func (c *wsClient) reply(message wsServerMessage) {
+	select {
+	case c.replies <- message:
+	default:
+		c.conn.Close()
+	}
+}
+
+func (c *wsClient) writeLoop(events <-chan event) {
+	ping := time.NewTicker(wsPingInterval)
+	defer func() {
+		ping.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case message := <-c.replies:
+			if err := c.write(message); err != nil {
+				return
+			}
+		case e, ok := <-events:
+			if !ok {
+				// The broker closes the channel when it shuts down or when
+				// this client fell too far behind to keep up.
+				code, text := websocket.CloseTryAgainLater, "too slow to keep up"
+				if c.cfg.broker.isClosed() {
+					code, text = websocket.CloseGoingAway, "server shutting down"
+				}
+
+				c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, text), time.Now().Add(wsWriteWait))
+				return
+			}
+
+			if e.Kind == eventRelationsChanged {
+				if e.UserId == c.userId && c.refresh() != nil {
+					c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "couldn't refresh"), time.Now().Add(wsWriteWait))
+					return
+				}
+				continue
+			}
+
+			for _, message := range c.route(e) {
+				if err := c.write(message); err != nil {
+					return
+				}
+			}
+		case <-ping.C:
+			err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (c *wsClient) write(message wsServerMessage) error {
+	c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait))
+	return c.conn.WriteJSON(message)
+}
+
+// route returns a message for each of the client's subscriptions that e
+// belongs to.
+func (c *wsClient) route(e event) []wsServerMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var messages []wsServerMessage
+	newMessage := func(channel string) wsServerMessage {
+		return wsServerMessage{
+			Type:    "event",
+			Channel: channel,
+			Event:   e.Kind,
+			Id:      e.Id,
+			Data:    e.Data,
+		}
+	}
+
+	switch e.Kind {
+	case eventChirpCreated, eventChirpDeleted:
+		// Blocks hide chirps everywhere. Mutes only apply to the timeline, as
+		// they do for the REST feeds.
+		if c.audience.blocks(e) {
+			break
+		}
+
+		if _, ok := c.timeline[e.UserId]; ok && !c.audience.mutes(e) {
+			messages = append(messages, newMessage(wsChannelTimeline))
+		}
+
+		for rootId, chirpIds := range c.threads {
+			_, hasParent := chirpIds[e.ParentId]
+			_, hasChirp := chirpIds[e.ChirpId]
+
+			if e.Kind == eventChirpCreated && hasParent {
+				chirpIds[e.ChirpId] = struct{}{}
+				messages = append(messages, newMessage(wsChannelThreadPrefix+rootId.String()))
+			} else if e.Kind == eventChirpDeleted && hasChirp {
+				messages = append(messages, newMessage(wsChannelThreadPrefix+rootId.String()))
+			}
+		}
+	case eventNotificationCreated:
+		if c.notifications && e.UserId == c.userId {
+			messages = append(messages, newMessage(wsChannelNotifications))
+		}
+	}
+
+	return messages
+}
+
+func (c *wsClient) subscribe(channel string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	switch {
+	case channel == wsChannelTimeline:
+		timeline, err := c.loadTimeline(ctx)
+		if err != nil {
+			return errors.New("couldn't subscribe")
+		}
+
+		c.mu.Lock()
+		c.timeline = timeline
+		c.mu.Unlock()
+	case channel == wsChannelNotifications:
+		c.mu.Lock()
+		c.notifications = true
+		c.mu.Unlock()
+	case strings.HasPrefix(channel, wsChannelThreadPrefix):
+		rootId, err := uuid.Parse(strings.TrimPrefix(channel, wsChannelThreadPrefix))
+		if err != nil {
+			return errors.New("invalid channel")
+		}
+
+		c.mu.Lock()
+		threadCount := len(c.threads)
+		c.mu.Unlock()
+		if threadCount >= wsMaxThreadsPerCon {
+			return errors.New("too many thread subscriptions")
+		}
+
+		_, err = c.cfg.db.GetVisibleChirpById(ctx, database.GetVisibleChirpByIdParams{
+			ChirpID:  rootId,
+			ViewerID: c.userId,
+		})
+		if err != nil {
+			return errors.New("invalid channel")
+		}
+
+		descendantsDB, err := c.cfg.db.GetChirpDescendants(ctx, database.GetChirpDescendantsParams{
+			ChirpID:  rootId,
+			ViewerID: c.userId,
+			Limit:    maxThreadReplies,
+		})
+
+		if err != nil {
+			return errors.New("couldn't subscribe")
+		}
+
+		chirpIds := map[uuid.UUID]struct{}{rootId: {}}
+		for _, descendantDB := range descendantsDB {
+			chirpIds[descendantDB.ID] = struct{}{}
+		}
+
+		c.mu.Lock()
+		c.threads[rootId] = chirpIds
+		c.mu.Unlock()
+	default:
+		return errors.New("invalid channel")
+	}
+
+	return nil
+}
+
+// loadTimeline returns the authors whose chirps make up the user's
+// timeline.
+func (c *wsClient) loadTimeline(ctx context.Context) (map[uuid.UUID]struct{}, error) {
+	followingIds, err := c.cfg.db.GetFollowingIds(ctx, c.userId)
+	if err != nil {
+		return nil, err
+	}
+
+	timeline := map[uuid.UUID]struct{}{c.userId: {}}
+	for _, followingId := range followingIds {
+		timeline[followingId] = struct{}{}
+	}
+
+	return timeline, nil
+}
+
+// refresh reloads the user's audience, and their timeline if they're
+// subscribed to it, after they follow, block or mute someone or are
+// blocked.
+func (c *wsClient) refresh() error {
+	aud, err := c.cfg.loadAudience(c.userId)
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	subscribed := c.timeline != nil
+	c.mu.Unlock()
+
+	var timeline map[uuid.UUID]struct{}
+	if subscribed {
+		ctx, cancel := context.WithTimeout(context.Background(), audienceLoadTimeout)
+		defer cancel()
+
+		timeline, err = c.loadTimeline(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.audience = aud
+	if subscribed && c.timeline != nil {
+		c.timeline = timeline
+	}
+
+	return nil
+}
+
+func (c *wsClient) unsubscribe(channel string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch {
+	case channel == wsChannelTimeline:
+		c.timeline = nil
+	case channel == wsChannelNotifications:
+		c.notifications = false
+	case strings.HasPrefix(channel, wsChannelThreadPrefix):
+		rootId, err := uuid.Parse(strings.TrimPrefix(channel, wsChannelThreadPrefix))
+		if err == nil {
+			delete(c.threads, rootId)
+		}
+	}
+}
@@ -27,53 +27,143 @@ func CheckPasswordHash(password, hash string) error {
 	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
 }
 
-func MakeJWT(userId uuid.UUID, secretKey string, expiresIn time.Duration) (string, error) {
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
-		Issuer:    "chirpy",
-		IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
-		ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
-		Subject:   userId.String(),
+// Role decides what a user is allowed to do. Each role can do everything
+// the ones below it can.
+type Role string
+
+const (
+	RoleUser      Role = "user"
+	RoleModerator Role = "moderator"
+	RoleAdmin     Role = "admin"
+)
+
+var roleRanks = map[Role]int{
+	RoleUser:      0,
+	RoleModerator: 1,
+	RoleAdmin:     2,
+}
+
+// Valid reports whether r is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := roleRanks[r]
+	return ok
+}
+
+// Includes reports whether r is allowed everything min is.
+func (r Role) Includes(min Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[min]
+}
+
+// jwtClaims is the payload of an access token. Scope is a space-separated
+// list, as in RFC 9068.
+type jwtClaims struct {
+	jwt.RegisteredClaims
+	Role  Role   `json:"role,omitempty"`
+	Scope string `json:"scope,omitempty"`
+}
+
+// Principal is who a valid access token says its holder is and what it lets
+// them do.
+type Principal struct {
+	UserId  uuid.UUID
+	Role    Role
+	TokenId string
+	// Scopes are the token's scope claim, nil when it has none. They're
+	// carried for handlers to read; no route is limited by them.
+	Scopes []string
+}
+
+// HasRole reports whether p is allowed everything min is.
+func (p Principal) HasRole(min Role) bool {
+	return p.Role.Includes(min)
+}
+
+// MakeJWT issues an access token for userId. Passing scopes limits what the
+// token can be used for.
+func MakeJWT(userId uuid.UUID, role Role, secretKey string, expiresIn time.Duration, scopes ...string) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwtClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			Issuer:    "chirpy",
+			IssuedAt:  jwt.NewNumericDate(time.Now().UTC()),
+			ExpiresAt: jwt.NewNumericDate(time.Now().UTC().Add(expiresIn)),
+			Subject:   userId.String(),
+		},
+		Role:  role,
+		Scope: strings.Join(scopes, " "),
 	})
 
 	key := []byte(secretKey)
 	return token.SignedString(key)
 }
 
-func ValidateJWT(tokenString, secretKey string) (uuid.UUID, error) {
+// ParseJWT validates an access token and returns its holder. Tokens issued
+// before roles existed are treated as RoleUser.
+func ParseJWT(tokenString, secretKey string) (Principal, error) {
 	key := []byte(secretKey)
-	claims := jwt.RegisteredClaims{}
+	claims := jwtClaims{}
 	token, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
 		return key, nil
-	})
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 	if err != nil {
-		return uuid.Nil, err
+		return Principal{}, err
 	}
 
 	if !token.Valid {
-		return uuid.Nil, errors.New("invalid token")
+		return Principal{}, errors.New("invalid token")
 	}
 
 	userIdString, err := token.Claims.GetSubject()
 	if err != nil {
-		return uuid.Nil, err
+		return Principal{}, err
 	}
 
 	issuer, err := token.Claims.GetIssuer()
 	if err != nil {
-		return uuid.Nil, err
+		return Principal{}, err
 	}
 
 	if issuer != "chirpy" {
-		return uuid.Nil, errors.New("invalid issuer")
+		return Principal{}, errors.New("invalid issuer")
 	}
 
 	id, err := uuid.Parse(userIdString)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("invalid user ID: %w", err)
+		return Principal{}, fmt.Errorf("invalid user ID: %w", err)
+	}
+
+	role := claims.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	if !role.Valid() {
+		return Principal{}, errors.New("invalid role")
+	}
+
+	principal := Principal{
+		UserId:  id,
+		Role:    role,
+		TokenId: claims.ID,
+	}
+
+	if claims.Scope != "" {
+		principal.Scopes = strings.Fields(claims.Scope)
+	}
+
+	return principal, nil
+}
+
+// ValidateJWT validates an access token and returns the ID of its holder.
+func ValidateJWT(tokenString, secretKey string) (uuid.UUID, error) {
+	principal, err := ParseJWT(tokenString, secretKey)
+	if err != nil {
+		return uuid.Nil, err
 	}
 
-	return id, nil
+	return principal.UserId, nil
 }
 
 func GetBearerToken(headers http.Header) (string, error) {
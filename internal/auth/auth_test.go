@@ -1,7 +1,12 @@
 package auth
 
 import (
+	"context"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 func TestCheckPasswordHash(t *testing.T) {
@@ -57,3 +62,151 @@ func TestCheckPasswordHash(t *testing.T) {
 		})
 	}
 }
+
+func TestParseJWT(t *testing.T) {
+	userId := uuid.New()
+	secret := "secret"
+
+	adminToken, _ := MakeJWT(userId, RoleAdmin, secret, time.Hour)
+	noRoleToken, _ := MakeJWT(userId, "", secret, time.Hour)
+	badRoleToken, _ := MakeJWT(userId, "owner", secret, time.Hour)
+	expiredToken, _ := MakeJWT(userId, RoleUser, secret, -time.Minute)
+	scopedToken, _ := MakeJWT(userId, RoleUser, secret, time.Hour, "chirps:read", "chirps:write")
+
+	tests := []struct {
+		name    string
+		token   string
+		secret  string
+		want    Principal
+		wantErr bool
+	}{
+		{
+			name:   "Valid token",
+			token:  adminToken,
+			secret: secret,
+			want:   Principal{UserId: userId, Role: RoleAdmin},
+		},
+		{
+			name:   "Token without a role",
+			token:  noRoleToken,
+			secret: secret,
+			want:   Principal{UserId: userId, Role: RoleUser},
+		},
+		{
+			name:   "Scoped token",
+			token:  scopedToken,
+			secret: secret,
+			want: Principal{
+				UserId: userId,
+				Role:   RoleUser,
+				Scopes: []string{"chirps:read", "chirps:write"},
+			},
+		},
+		{
+			name:    "Unknown role",
+			token:   badRoleToken,
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "Wrong secret",
+			token:   adminToken,
+			secret:  "wrong",
+			wantErr: true,
+		},
+		{
+			name:    "Expired token",
+			token:   expiredToken,
+			secret:  secret,
+			wantErr: true,
+		},
+		{
+			name:    "Not a token",
+			token:   "invalid",
+			secret:  secret,
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseJWT(test.token, test.secret)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("ParseJWT() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.TokenId == "" {
+				t.Errorf("ParseJWT() TokenId is empty")
+			}
+			got.TokenId = ""
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParseJWT() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRoleIncludes(t *testing.T) {
+	tests := []struct {
+		role Role
+		min  Role
+		want bool
+	}{
+		{RoleAdmin, RoleModerator, true},
+		{RoleModerator, RoleModerator, true},
+		{RoleModerator, RoleAdmin, false},
+		{RoleUser, RoleModerator, false},
+		{"owner", RoleUser, false},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.role)+" includes "+string(test.min), func(t *testing.T) {
+			got := test.role.Includes(test.min)
+			if got != test.want {
+				t.Errorf("Includes() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	want := Principal{UserId: uuid.New(), Role: RoleModerator, TokenId: "abc"}
+
+	got, ok := FromContext(NewContext(context.Background(), want))
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Errorf("FromContext() = %v, %v, want %v, true", got, ok, want)
+	}
+
+	_, ok = FromContext(context.Background())
+	if ok {
+		t.Errorf("FromContext() on an empty context found a principal")
+	}
+
+	if id := UserIdFromContext(context.Background()); id != uuid.Nil {
+		t.Errorf("UserIdFromContext() = %v, want uuid.Nil", id)
+	}
+}
+
+func TestChallenge(t *testing.T) {
+	tests := []struct {
+		name        string
+		errCode     string
+		description string
+		want        string
+	}{
+		{"No credentials", "", "Couldn't find JWT", `Bearer realm="chirpy"`},
+		{"Invalid token", ErrInvalidToken, "Token expired", `Bearer realm="chirpy", error="invalid_token", error_description="Token expired"`},
+		{"Insufficient scope", ErrInsufficientScope, "", `Bearer realm="chirpy", error="insufficient_scope"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Challenge("chirpy", test.errCode, test.description)
+			if got != test.want {
+				t.Errorf("Challenge() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+type contextKey struct{}
+
+// NewContext returns a copy of ctx carrying p as the authenticated
+// principal.
+func NewContext(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, p)
+}
+
+// FromContext returns the authenticated principal in ctx, if there is one.
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(contextKey{}).(Principal)
+	return p, ok
+}
+
+// UserIdFromContext returns the ID of the authenticated user in ctx, or
+// uuid.Nil if the request is anonymous.
+func UserIdFromContext(ctx context.Context) uuid.UUID {
+	p, _ := FromContext(ctx)
+	return p.UserId
+}
+
+// Error codes for Challenge, from RFC 6750.
+const (
+	ErrInvalidToken      = "invalid_token"
+	ErrInsufficientScope = "insufficient_scope"
+)
+
+// Challenge builds a WWW-Authenticate header value for a bearer token
+// failure. When the request had no credentials at all, errCode should be
+// empty and the description is left out too, as RFC 6750 asks.
+func Challenge(realm, errCode, description string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Bearer realm=%q", realm)
+	if errCode == "" {
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, ", error=%q", errCode)
+	if description != "" {
+		fmt.Fprintf(&b, ", error_description=%q", description)
+	}
+	return b.String()
+}
@@ -0,0 +1,134 @@
+package chirptext
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+const maxHashtagLength = 50
+
+// A hashtag starts at the beginning of the body or after a character that
+// can't be part of a word, so "a#b" and "&#39;" are left alone.
+var hashtagRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&#])#([\p{L}\p{N}_]+)`)
+
+// Hashtags returns the distinct hashtags in body, lowercased and without the
+// leading '#', in the order they first appear.
+func Hashtags(body string) []string {
+	matches := hashtagRegex.FindAllStringSubmatch(body, -1)
+
+	seen := make(map[string]struct{}, len(matches))
+	tags := make([]string, 0, len(matches))
+	for _, match := range matches {
+		tag := strings.ToLower(match[1])
+		if len([]rune(tag)) > maxHashtagLength {
+			continue
+		}
+
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+
+		seen[tag] = struct{}{}
+		tags = append(tags, tag)
+	}
+
+	return tags
+}
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 20
+)
+
+var usernameRegex = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// ValidUsername reports whether username can be registered and mentioned:
+// 3 to 20 ASCII letters, digits or underscores.
+func ValidUsername(username string) bool {
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return false
+	}
+
+	return usernameRegex.MatchString(username)
+}
+
+var mentionRegex = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_@])(@([A-Za-z0-9_]+))`)
+
+// Mention is an @username reference in a chirp body. Start and End are
+// offsets in Unicode code points, with End exclusive and Start pointing at
+// the '@'.
+type Mention struct {
+	Username string
+	Start    int
+	End      int
+}
+
+// Mentions returns every @username in body that could be a valid username,
+// in the order they appear. The same user may be mentioned more than once.
+func Mentions(body string) []Mention {
+	matches := mentionRegex.FindAllStringSubmatchIndex(body, -1)
+
+	mentions := make([]Mention, 0, len(matches))
+	for _, match := range matches {
+		start, end := match[2], match[3]
+		username := body[match[4]:match[5]]
+		if !ValidUsername(username) {
+			continue
+		}
+
+		startRunes := utf8.RuneCountInString(body[:start])
+		mentions = append(mentions, Mention{
+			Username: username,
+			Start:    startRunes,
+			End:      startRunes + utf8.RuneCountInString(body[start:end]),
+		})
+	}
+
+	return mentions
+}
+
+// URLWeight is how many characters a link counts as, up to MaxURLLength.
+const URLWeight = 23
+
+// MaxURLLength is the longest link, in bytes, that counts as URLWeight.
+// Anything past it counts like ordinary text, so a body can't hide an
+// arbitrary amount of text in one link.
+const MaxURLLength = 512
+
+var urlRegex = regexp.MustCompile(`https?://[^\s]+`)
+
+// Normalize returns body in Unicode NFC form, so the same text is always
+// stored, matched and measured the same way.
+func Normalize(body string) string {
+	return norm.NFC.String(body)
+}
+
+// Length returns how long body is as users see it: the number of grapheme
+// clusters after normalization, so an emoji or an accented letter counts
+// once, with each http(s) link counted as URLWeight characters and the part
+// of a link past MaxURLLength counted as text.
+func Length(body string) int {
+	body = Normalize(body)
+
+	length := 0
+	last := 0
+	for _, loc := range urlRegex.FindAllStringIndex(body, -1) {
+		// Punctuation at the end belongs to the sentence, not the link.
+		url := strings.TrimRight(body[loc[0]:loc[1]], ".,:;!?'\")]}")
+		if len(url) > MaxURLLength {
+			url = url[:MaxURLLength]
+			for !utf8.ValidString(url) {
+				url = url[:len(url)-1]
+			}
+		}
+
+		length += uniseg.GraphemeClusterCount(body[last:loc[0]]) + URLWeight
+		last = loc[0] + len(url)
+	}
+
+	return length + uniseg.GraphemeClusterCount(body[last:])
+}
@@ -0,0 +1,159 @@
+package chirptext
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "No hashtags",
+			body: "just a regular chirp",
+			want: []string{},
+		},
+		{
+			name: "Single hashtag",
+			body: "learning #golang today",
+			want: []string{"golang"},
+		},
+		{
+			name: "Lowercased and deduplicated",
+			body: "#Go is great, #go is fun, #GO!",
+			want: []string{"go"},
+		},
+		{
+			name: "Trailing punctuation",
+			body: "ship it (#release), then #party!",
+			want: []string{"release", "party"},
+		},
+		{
+			name: "Not in the middle of a word",
+			body: "email me at a#b or &#39;",
+			want: []string{},
+		},
+		{
+			name: "Non-Latin hashtag",
+			body: "#日本語 and #café",
+			want: []string{"日本語", "café"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Hashtags(test.body)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Hashtags() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []Mention
+	}{
+		{
+			name: "No mentions",
+			body: "hello world",
+			want: []Mention{},
+		},
+		{
+			name: "Single mention",
+			body: "hi @alice!",
+			want: []Mention{{Username: "alice", Start: 3, End: 9}},
+		},
+		{
+			name: "Offsets count code points",
+			body: "héllo 👋 @bob_99 and @carol",
+			want: []Mention{
+				{Username: "bob_99", Start: 8, End: 15},
+				{Username: "carol", Start: 20, End: 26},
+			},
+		},
+		{
+			name: "Email addresses are not mentions",
+			body: "mail me at dave@example.com",
+			want: []Mention{},
+		},
+		{
+			name: "Too short or too long",
+			body: "@ab @abcdefghijklmnopqrstu",
+			want: []Mention{},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Mentions(test.body)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Mentions() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLength(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{
+			name: "ASCII",
+			body: "hello world",
+			want: 11,
+		},
+		{
+			name: "Non-Latin text",
+			body: "こんにちは世界",
+			want: 7,
+		},
+		{
+			name: "Emoji with modifiers count once",
+			body: "hi 👋🏽 👨‍👩‍👧",
+			want: 6,
+		},
+		{
+			name: "Decomposed accents are normalized",
+			body: "café",
+			want: 4,
+		},
+		{
+			name: "Links have a fixed weight",
+			body: "read https://example.com/a/very/long/path?with=query",
+			want: 5 + URLWeight,
+		},
+		{
+			name: "Trailing punctuation isn't part of a link",
+			body: "see http://a.co.",
+			want: 4 + URLWeight + 1,
+		},
+		{
+			name: "Several links",
+			body: "https://a.co https://b.co",
+			want: 2*URLWeight + 1,
+		},
+		{
+			name: "Links past the maximum length count as text",
+			body: "https://a.co/" + strings.Repeat("a", MaxURLLength),
+			want: URLWeight + len("https://a.co/"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Length(test.body)
+			if got != test.want {
+				t.Errorf("Length() = %d, want %d", got, test.want)
+			}
+		})
+	}
+}
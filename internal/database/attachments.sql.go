@@ -0,0 +1,223 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: attachments.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const attachToChirp = `-- name: AttachToChirp :execrows
+UPDATE attachments
+SET chirp_id = $1::uuid, position = array_position($2::uuid[], id)
+WHERE id = ANY($2::uuid[])
+  AND user_id = $3
+  AND chirp_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM users AS u WHERE u.avatar_id = attachments.id)
+`
+
+type AttachToChirpParams struct {
+	ChirpID       uuid.UUID
+	AttachmentIds []uuid.UUID
+	UserID        uuid.UUID
+}
+
+func (q *Queries) AttachToChirp(ctx context.Context, arg AttachToChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, attachToChirp, arg.ChirpID, pq.Array(arg.AttachmentIds), arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countAttachable = `-- name: CountAttachable :one
+SELECT COUNT(*)
+FROM attachments
+WHERE id = ANY($1::uuid[])
+  AND user_id = $2
+  AND chirp_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM users AS u WHERE u.avatar_id = attachments.id)
+`
+
+type CountAttachableParams struct {
+	AttachmentIds []uuid.UUID
+	UserID        uuid.UUID
+}
+
+func (q *Queries) CountAttachable(ctx context.Context, arg CountAttachableParams) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAttachable, pq.Array(arg.AttachmentIds), arg.UserID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createAttachment = `-- name: CreateAttachment :one
+INSERT INTO attachments (id, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key, created_at)
+VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, NOW()
+)
+RETURNING id, user_id, chirp_id, position, content_type, size_bytes, width, height, storage_key, thumbnail_key, created_at
+`
+
+type CreateAttachmentParams struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	ContentType  string
+	SizeBytes    int32
+	Width        int32
+	Height       int32
+	StorageKey   string
+	ThumbnailKey string
+}
+
+func (q *Queries) CreateAttachment(ctx context.Context, arg CreateAttachmentParams) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, createAttachment,
+		arg.ID,
+		arg.UserID,
+		arg.ContentType,
+		arg.SizeBytes,
+		arg.Width,
+		arg.Height,
+		arg.StorageKey,
+		arg.ThumbnailKey,
+	)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ChirpID,
+		&i.Position,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Width,
+		&i.Height,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteAttachment = `-- name: DeleteAttachment :one
+DELETE FROM attachments
+WHERE id = $1
+RETURNING storage_key, thumbnail_key
+`
+
+type DeleteAttachmentRow struct {
+	StorageKey   string
+	ThumbnailKey string
+}
+
+func (q *Queries) DeleteAttachment(ctx context.Context, id uuid.UUID) (DeleteAttachmentRow, error) {
+	row := q.db.QueryRowContext(ctx, deleteAttachment, id)
+	var i DeleteAttachmentRow
+	err := row.Scan(&i.StorageKey, &i.ThumbnailKey)
+	return i, err
+}
+
+const deleteAttachments = `-- name: DeleteAttachments :many
+DELETE FROM attachments
+WHERE chirp_id = $1::uuid
+RETURNING storage_key, thumbnail_key
+`
+
+type DeleteAttachmentsRow struct {
+	StorageKey   string
+	ThumbnailKey string
+}
+
+func (q *Queries) DeleteAttachments(ctx context.Context, chirpID uuid.UUID) ([]DeleteAttachmentsRow, error) {
+	rows, err := q.db.QueryContext(ctx, deleteAttachments, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []DeleteAttachmentsRow
+	for rows.Next() {
+		var i DeleteAttachmentsRow
+		if err := rows.Scan(&i.StorageKey, &i.ThumbnailKey); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getAttachmentById = `-- name: GetAttachmentById :one
+SELECT id, user_id, chirp_id, position, content_type, size_bytes, width, height, storage_key, thumbnail_key, created_at
+FROM attachments
+WHERE id = $1
+`
+
+func (q *Queries) GetAttachmentById(ctx context.Context, id uuid.UUID) (Attachment, error) {
+	row := q.db.QueryRowContext(ctx, getAttachmentById, id)
+	var i Attachment
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ChirpID,
+		&i.Position,
+		&i.ContentType,
+		&i.SizeBytes,
+		&i.Width,
+		&i.Height,
+		&i.StorageKey,
+		&i.ThumbnailKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getAttachments = `-- name: GetAttachments :many
+SELECT id, user_id, chirp_id, position, content_type, size_bytes, width, height, storage_key, thumbnail_key, created_at
+FROM attachments
+WHERE chirp_id = ANY($1::uuid[])
+ORDER BY chirp_id, position
+`
+
+func (q *Queries) GetAttachments(ctx context.Context, chirpIds []uuid.UUID) ([]Attachment, error) {
+	rows, err := q.db.QueryContext(ctx, getAttachments, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Attachment
+	for rows.Next() {
+		var i Attachment
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ChirpID,
+			&i.Position,
+			&i.ContentType,
+			&i.SizeBytes,
+			&i.Width,
+			&i.Height,
+			&i.StorageKey,
+			&i.ThumbnailKey,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
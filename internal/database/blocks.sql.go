@@ -0,0 +1,298 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: blocks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createBlock = `-- name: CreateBlock :execrows
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreateBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) CreateBlock(ctx context.Context, arg CreateBlockParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createBlock, arg.BlockerID, arg.BlockedID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createMute = `-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreateMuteParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) CreateMute(ctx context.Context, arg CreateMuteParams) error {
+	_, err := q.db.ExecContext(ctx, createMute, arg.MuterID, arg.MutedID)
+	return err
+}
+
+const deleteBlock = `-- name: DeleteBlock :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2
+`
+
+type DeleteBlockParams struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+}
+
+func (q *Queries) DeleteBlock(ctx context.Context, arg DeleteBlockParams) error {
+	_, err := q.db.ExecContext(ctx, deleteBlock, arg.BlockerID, arg.BlockedID)
+	return err
+}
+
+const deleteMute = `-- name: DeleteMute :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2
+`
+
+type DeleteMuteParams struct {
+	MuterID uuid.UUID
+	MutedID uuid.UUID
+}
+
+func (q *Queries) DeleteMute(ctx context.Context, arg DeleteMuteParams) error {
+	_, err := q.db.ExecContext(ctx, deleteMute, arg.MuterID, arg.MutedID)
+	return err
+}
+
+const getBlockedUsers = `-- name: GetBlockedUsers :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, b.created_at AS blocked_at
+FROM blocks AS b
+JOIN users AS u ON u.id = b.blocked_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE b.blocker_id = $1
+  AND (
+    $2::timestamp IS NULL
+    OR (b.created_at, u.id) < ($2::timestamp, $3::uuid)
+  )
+ORDER BY b.created_at DESC, u.id DESC
+LIMIT $4
+`
+
+type GetBlockedUsersParams struct {
+	UserID          uuid.UUID
+	CursorBlockedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type GetBlockedUsersRow struct {
+	ID          uuid.UUID
+	Username    sql.NullString
+	DisplayName string
+	AvatarKey   sql.NullString
+	IsChirpyRed bool
+	CreatedAt   time.Time
+	BlockedAt   time.Time
+}
+
+func (q *Queries) GetBlockedUsers(ctx context.Context, arg GetBlockedUsersParams) ([]GetBlockedUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBlockedUsers,
+		arg.UserID,
+		arg.CursorBlockedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBlockedUsersRow
+	for rows.Next() {
+		var i GetBlockedUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarKey,
+			&i.IsChirpyRed,
+			&i.CreatedAt,
+			&i.BlockedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBlockerIds = `-- name: GetBlockerIds :many
+SELECT blocker_id
+FROM blocks
+WHERE blocked_id = $1
+`
+
+// Users who have blocked this one, whose chirps it mustn't be sent.
+func (q *Queries) GetBlockerIds(ctx context.Context, blockedID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getBlockerIds, blockedID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var blocker_id uuid.UUID
+		if err := rows.Scan(&blocker_id); err != nil {
+			return nil, err
+		}
+		items = append(items, blocker_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMutedIds = `-- name: GetMutedIds :many
+SELECT muted_id
+FROM mutes
+WHERE muter_id = $1
+`
+
+func (q *Queries) GetMutedIds(ctx context.Context, muterID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getMutedIds, muterID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var muted_id uuid.UUID
+		if err := rows.Scan(&muted_id); err != nil {
+			return nil, err
+		}
+		items = append(items, muted_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMutedUsers = `-- name: GetMutedUsers :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, m.created_at AS muted_at
+FROM mutes AS m
+JOIN users AS u ON u.id = m.muted_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE m.muter_id = $1
+  AND (
+    $2::timestamp IS NULL
+    OR (m.created_at, u.id) < ($2::timestamp, $3::uuid)
+  )
+ORDER BY m.created_at DESC, u.id DESC
+LIMIT $4
+`
+
+type GetMutedUsersParams struct {
+	UserID        uuid.UUID
+	CursorMutedAt sql.NullTime
+	CursorID      uuid.NullUUID
+	Limit         int32
+}
+
+type GetMutedUsersRow struct {
+	ID          uuid.UUID
+	Username    sql.NullString
+	DisplayName string
+	AvatarKey   sql.NullString
+	IsChirpyRed bool
+	CreatedAt   time.Time
+	MutedAt     time.Time
+}
+
+func (q *Queries) GetMutedUsers(ctx context.Context, arg GetMutedUsersParams) ([]GetMutedUsersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMutedUsers,
+		arg.UserID,
+		arg.CursorMutedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMutedUsersRow
+	for rows.Next() {
+		var i GetMutedUsersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarKey,
+			&i.IsChirpyRed,
+			&i.CreatedAt,
+			&i.MutedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isBlockedBetween = `-- name: IsBlockedBetween :one
+SELECT EXISTS (
+  SELECT 1
+  FROM blocks
+  WHERE (blocker_id = $1 AND blocked_id = $2)
+    OR (blocker_id = $2 AND blocked_id = $1)
+)::boolean AS blocked
+`
+
+type IsBlockedBetweenParams struct {
+	UserID  uuid.UUID
+	OtherID uuid.UUID
+}
+
+// Reports whether either user has blocked the other.
+func (q *Queries) IsBlockedBetween(ctx context.Context, arg IsBlockedBetweenParams) (bool, error) {
+	row := q.db.QueryRowContext(ctx, isBlockedBetween, arg.UserID, arg.OtherID)
+	var blocked bool
+	err := row.Scan(&blocked)
+	return blocked, err
+}
@@ -0,0 +1,159 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: bookmarks.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createBookmark = `-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreateBookmarkParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) CreateBookmark(ctx context.Context, arg CreateBookmarkParams) error {
+	_, err := q.db.ExecContext(ctx, createBookmark, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const deleteBookmark = `-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2
+`
+
+type DeleteBookmarkParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) DeleteBookmark(ctx context.Context, arg DeleteBookmarkParams) error {
+	_, err := q.db.ExecContext(ctx, deleteBookmark, arg.UserID, arg.ChirpID)
+	return err
+}
+
+const getBookmarkedChirpIds = `-- name: GetBookmarkedChirpIds :many
+SELECT chirp_id
+FROM bookmarks
+WHERE user_id = $1
+  AND chirp_id = ANY($2::uuid[])
+`
+
+type GetBookmarkedChirpIdsParams struct {
+	UserID   uuid.UUID
+	ChirpIds []uuid.UUID
+}
+
+func (q *Queries) GetBookmarkedChirpIds(ctx context.Context, arg GetBookmarkedChirpIdsParams) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarkedChirpIds, arg.UserID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var chirp_id uuid.UUID
+		if err := rows.Scan(&chirp_id); err != nil {
+			return nil, err
+		}
+		items = append(items, chirp_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getBookmarks = `-- name: GetBookmarks :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at, b.created_at AS bookmarked_at
+FROM bookmarks AS b
+JOIN chirps AS c ON c.id = b.chirp_id
+WHERE b.user_id = $1
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $1)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS bl
+    WHERE bl.blocked_id = $1
+      AND bl.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND (
+    $2::timestamp IS NULL
+    OR (b.created_at, c.id) < ($2::timestamp, $3::uuid)
+  )
+ORDER BY b.created_at DESC, c.id DESC
+LIMIT $4
+`
+
+type GetBookmarksParams struct {
+	UserID             uuid.UUID
+	CursorBookmarkedAt sql.NullTime
+	CursorID           uuid.NullUUID
+	Limit              int32
+}
+
+type GetBookmarksRow struct {
+	Chirp        Chirp
+	BookmarkedAt time.Time
+}
+
+func (q *Queries) GetBookmarks(ctx context.Context, arg GetBookmarksParams) ([]GetBookmarksRow, error) {
+	rows, err := q.db.QueryContext(ctx, getBookmarks,
+		arg.UserID,
+		arg.CursorBookmarkedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetBookmarksRow
+	for rows.Next() {
+		var i GetBookmarksRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.ParentID,
+			&i.Chirp.IsTombstone,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.HiddenAt,
+			&i.BookmarkedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
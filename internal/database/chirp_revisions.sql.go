@@ -0,0 +1,85 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: chirp_revisions.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getChirpRevisions = `-- name: GetChirpRevisions :many
+SELECT id, chirp_id, body, created_at
+FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC
+`
+
+func (q *Queries) GetChirpRevisions(ctx context.Context, chirpID uuid.UUID) ([]ChirpRevision, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpRevisions, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ChirpRevision
+	for rows.Next() {
+		var i ChirpRevision
+		if err := rows.Scan(
+			&i.ID,
+			&i.ChirpID,
+			&i.Body,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateChirpBody = `-- name: UpdateChirpBody :one
+WITH revision AS (
+  INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+  SELECT gen_random_uuid(), c.id, c.body, COALESCE(c.edited_at, c.created_at)
+  FROM chirps AS c
+  WHERE c.id = $2
+)
+UPDATE chirps
+SET body = $1, edited_at = NOW(), updated_at = NOW()
+WHERE chirps.id = $2
+RETURNING id, user_id, body, created_at, updated_at, parent_id, is_tombstone, rechirp_of_id, quote_of_id, edited_at, deleted_at, hidden_at
+`
+
+type UpdateChirpBodyParams struct {
+	Body string
+	ID   uuid.UUID
+}
+
+func (q *Queries) UpdateChirpBody(ctx context.Context, arg UpdateChirpBodyParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, updateChirpBody, arg.Body, arg.ID)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentID,
+		&i.IsTombstone,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+	)
+	return i, err
+}
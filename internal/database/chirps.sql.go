@@ -7,25 +7,37 @@ package database
 
 import (
 	"context"
+	"database/sql"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createChirp = `-- name: CreateChirp :one
-INSERT INTO chirps (id, user_id, body, created_at, updated_at)
+INSERT INTO chirps (id, user_id, body, parent_id, rechirp_of_id, quote_of_id, created_at, updated_at)
 VALUES (
-  gen_random_uuid(), $1, $2, NOW(), NOW()
+  gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW()
 )
-RETURNING id, user_id, body, created_at, updated_at
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL DO NOTHING
+RETURNING id, user_id, body, created_at, updated_at, parent_id, is_tombstone, rechirp_of_id, quote_of_id, edited_at, deleted_at, hidden_at
 `
 
 type CreateChirpParams struct {
-	UserID uuid.UUID
-	Body   string
+	UserID      uuid.UUID
+	Body        string
+	ParentID    uuid.NullUUID
+	RechirpOfID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
 }
 
 func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, createChirp, arg.UserID, arg.Body)
+	row := q.db.QueryRowContext(ctx, createChirp,
+		arg.UserID,
+		arg.Body,
+		arg.ParentID,
+		arg.RechirpOfID,
+		arg.QuoteOfID,
+	)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -33,6 +45,13 @@ func (q *Queries) CreateChirp(ctx context.Context, arg CreateChirpParams) (Chirp
 		&i.Body,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentID,
+		&i.IsTombstone,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
 	)
 	return i, err
 }
@@ -47,14 +66,34 @@ func (q *Queries) DeleteChirp(ctx context.Context, id uuid.UUID) error {
 	return err
 }
 
-const getAllChirps = `-- name: GetAllChirps :many
-SELECT id, user_id, body, created_at, updated_at
-FROM chirps
-ORDER BY created_at
+const getChirpAncestors = `-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+  SELECT c.id, c.parent_id, 1 AS depth
+  FROM chirps AS c
+  WHERE c.id = (SELECT parent_id FROM chirps WHERE chirps.id = $2)
+  UNION ALL
+  SELECT c.id, c.parent_id, a.depth + 1
+  FROM chirps AS c
+  JOIN ancestors AS a ON c.id = a.parent_id
+)
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+JOIN ancestors AS a ON a.id = c.id
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM blocks AS b
+  WHERE b.blocker_id = c.user_id AND b.blocked_id = $1
+)
+ORDER BY a.depth DESC
 `
 
-func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirps)
+type GetChirpAncestorsParams struct {
+	ViewerID uuid.UUID
+	ChirpID  uuid.UUID
+}
+
+func (q *Queries) GetChirpAncestors(ctx context.Context, arg GetChirpAncestorsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpAncestors, arg.ViewerID, arg.ChirpID)
 	if err != nil {
 		return nil, err
 	}
@@ -68,6 +107,13 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 			&i.Body,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -82,14 +128,72 @@ func (q *Queries) GetAllChirps(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getAllChirpsDesc = `-- name: GetAllChirpsDesc :many
-SELECT id, user_id, body, created_at, updated_at
+const getChirpById = `-- name: GetChirpById :one
+SELECT id, user_id, body, created_at, updated_at, parent_id, is_tombstone, rechirp_of_id, quote_of_id, edited_at, deleted_at, hidden_at
 FROM chirps
-ORDER BY created_at DESC
+WHERE id = $1
+  AND deleted_at IS NULL
+`
+
+func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getChirpById, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentID,
+		&i.IsTombstone,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const getChirpDescendants = `-- name: GetChirpDescendants :many
+WITH RECURSIVE descendants AS (
+  SELECT c.id
+  FROM chirps AS c
+  WHERE c.parent_id = $2::uuid
+    AND (c.hidden_at IS NULL OR c.user_id = $3::uuid)
+    AND NOT EXISTS (
+      SELECT 1
+      FROM blocks AS b
+      WHERE b.blocker_id = c.user_id AND b.blocked_id = $3::uuid
+    )
+  UNION ALL
+  SELECT c.id
+  FROM chirps AS c
+  JOIN descendants AS d ON c.parent_id = d.id
+  WHERE (c.hidden_at IS NULL OR c.user_id = $3::uuid)
+    AND NOT EXISTS (
+      SELECT 1
+      FROM blocks AS b
+      WHERE b.blocker_id = c.user_id AND b.blocked_id = $3::uuid
+    )
+)
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+JOIN descendants AS d ON d.id = c.id
+ORDER BY c.created_at, c.id
+LIMIT $1
 `
 
-func (q *Queries) GetAllChirpsDesc(ctx context.Context) ([]Chirp, error) {
-	rows, err := q.db.QueryContext(ctx, getAllChirpsDesc)
+type GetChirpDescendantsParams struct {
+	Limit    int32
+	ChirpID  uuid.UUID
+	ViewerID uuid.UUID
+}
+
+// Replies by users who blocked the viewer, and hidden replies that aren't the
+// viewer's, are left out along with everything under them.
+func (q *Queries) GetChirpDescendants(ctx context.Context, arg GetChirpDescendantsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpDescendants, arg.Limit, arg.ChirpID, arg.ViewerID)
 	if err != nil {
 		return nil, err
 	}
@@ -103,6 +207,13 @@ func (q *Queries) GetAllChirpsDesc(ctx context.Context) ([]Chirp, error) {
 			&i.Body,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
 		); err != nil {
 			return nil, err
 		}
@@ -117,14 +228,373 @@ func (q *Queries) GetAllChirpsDesc(ctx context.Context) ([]Chirp, error) {
 	return items, nil
 }
 
-const getChirpById = `-- name: GetChirpById :one
-SELECT id, user_id, body, created_at, updated_at
+const getChirps = `-- name: GetChirps :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+WHERE NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $1)
+  AND ($2::uuid IS NULL OR c.user_id = $2)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM pinned_chirps AS p
+    WHERE p.user_id = $2 AND p.chirp_id = c.id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $1
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = $1 AND m.muted_id = c.user_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = $1
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = COALESCE(c.rechirp_of_id, c.id))
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  AND (
+    $3::timestamp IS NULL
+    OR (c.created_at, c.id) > ($3::timestamp, $4::uuid)
+  )
+ORDER BY c.created_at, c.id
+LIMIT $5
+`
+
+type GetChirpsParams struct {
+	ViewerID        uuid.UUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+// Leaves out chirps by users who blocked or were muted by the viewer, which
+// is uuid.Nil for anonymous requests, chirps with the viewer's muted keywords
+// and chirps hidden by moderators, unless the viewer wrote them. An author's
+// pinned chirps are left out of their chirps too, as they're shown first.
+func (q *Queries) GetChirps(ctx context.Context, arg GetChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirps,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsByIds = `-- name: GetChirpsByIds :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+WHERE c.id = ANY($1::uuid[])
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $2
+      AND b.blocker_id = c.user_id
+  )
+`
+
+type GetChirpsByIdsParams struct {
+	ChirpIds []uuid.UUID
+	ViewerID uuid.UUID
+}
+
+// Leaves out chirps whose author has blocked the viewer.
+func (q *Queries) GetChirpsByIds(ctx context.Context, arg GetChirpsByIdsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByIds, pq.Array(arg.ChirpIds), arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getChirpsDesc = `-- name: GetChirpsDesc :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+WHERE NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $1)
+  AND ($2::uuid IS NULL OR c.user_id = $2)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM pinned_chirps AS p
+    WHERE p.user_id = $2 AND p.chirp_id = c.id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $1
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = $1 AND m.muted_id = c.user_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = $1
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = COALESCE(c.rechirp_of_id, c.id))
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  AND (
+    $3::timestamp IS NULL
+    OR (c.created_at, c.id) < ($3::timestamp, $4::uuid)
+  )
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT $5
+`
+
+type GetChirpsDescParams struct {
+	ViewerID        uuid.UUID
+	AuthorID        uuid.NullUUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+// Leaves out chirps by users who blocked or were muted by the viewer, which
+// is uuid.Nil for anonymous requests, chirps with the viewer's muted keywords
+// and chirps hidden by moderators, unless the viewer wrote them. An author's
+// pinned chirps are left out of their chirps too, as they're shown first.
+func (q *Queries) GetChirpsDesc(ctx context.Context, arg GetChirpsDescParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsDesc,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getDeletedChirpById = `-- name: GetDeletedChirpById :one
+SELECT id, user_id, body, created_at, updated_at, parent_id, is_tombstone, rechirp_of_id, quote_of_id, edited_at, deleted_at, hidden_at
 FROM chirps
 WHERE id = $1
+  AND deleted_at IS NOT NULL
 `
 
-func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
-	row := q.db.QueryRowContext(ctx, getChirpById, id)
+func (q *Queries) GetDeletedChirpById(ctx context.Context, id uuid.UUID) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getDeletedChirpById, id)
+	var i Chirp
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ParentID,
+		&i.IsTombstone,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
+	)
+	return i, err
+}
+
+const getExpiredDeletedChirps = `-- name: GetExpiredDeletedChirps :many
+SELECT id, EXISTS (SELECT 1 FROM chirps AS r WHERE r.parent_id = c.id)::boolean AS has_replies
+FROM chirps AS c
+WHERE c.deleted_at <= NOW() - make_interval(secs => $1::float8)
+  AND NOT c.is_tombstone
+ORDER BY c.deleted_at
+LIMIT $2
+`
+
+type GetExpiredDeletedChirpsParams struct {
+	WindowSeconds float64
+	Limit         int32
+}
+
+type GetExpiredDeletedChirpsRow struct {
+	ID         uuid.UUID
+	HasReplies bool
+}
+
+func (q *Queries) GetExpiredDeletedChirps(ctx context.Context, arg GetExpiredDeletedChirpsParams) ([]GetExpiredDeletedChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getExpiredDeletedChirps, arg.WindowSeconds, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetExpiredDeletedChirpsRow
+	for rows.Next() {
+		var i GetExpiredDeletedChirpsRow
+		if err := rows.Scan(&i.ID, &i.HasReplies); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReplyCounts = `-- name: GetReplyCounts :many
+SELECT parent_id::uuid AS chirp_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY($1::uuid[])
+  AND NOT is_tombstone
+  AND deleted_at IS NULL
+GROUP BY parent_id
+`
+
+type GetReplyCountsRow struct {
+	ChirpID    uuid.UUID
+	ReplyCount int64
+}
+
+func (q *Queries) GetReplyCounts(ctx context.Context, chirpIds []uuid.UUID) ([]GetReplyCountsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getReplyCounts, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetReplyCountsRow
+	for rows.Next() {
+		var i GetReplyCountsRow
+		if err := rows.Scan(&i.ChirpID, &i.ReplyCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getVisibleChirpById = `-- name: GetVisibleChirpById :one
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+WHERE c.id = $1
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $2)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $2
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+`
+
+type GetVisibleChirpByIdParams struct {
+	ChirpID  uuid.UUID
+	ViewerID uuid.UUID
+}
+
+// Like GetChirpById, but as if the chirp didn't exist when its author has
+// blocked the viewer, or it was hidden by moderators and isn't the viewer's.
+func (q *Queries) GetVisibleChirpById(ctx context.Context, arg GetVisibleChirpByIdParams) (Chirp, error) {
+	row := q.db.QueryRowContext(ctx, getVisibleChirpById, arg.ChirpID, arg.ViewerID)
 	var i Chirp
 	err := row.Scan(
 		&i.ID,
@@ -132,6 +602,59 @@ func (q *Queries) GetChirpById(ctx context.Context, id uuid.UUID) (Chirp, error)
 		&i.Body,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.ParentID,
+		&i.IsTombstone,
+		&i.RechirpOfID,
+		&i.QuoteOfID,
+		&i.EditedAt,
+		&i.DeletedAt,
+		&i.HiddenAt,
 	)
 	return i, err
 }
+
+const restoreChirp = `-- name: RestoreChirp :execrows
+UPDATE chirps
+SET deleted_at = NULL
+WHERE (chirps.id = $1 OR chirps.rechirp_of_id = $1)
+  AND chirps.deleted_at = (SELECT c.deleted_at FROM chirps AS c WHERE c.id = $1)
+  AND chirps.deleted_at > NOW() - make_interval(secs => $2::float8)
+`
+
+type RestoreChirpParams struct {
+	ChirpID       uuid.UUID
+	WindowSeconds float64
+}
+
+func (q *Queries) RestoreChirp(ctx context.Context, arg RestoreChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, restoreChirp, arg.ChirpID, arg.WindowSeconds)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const softDeleteChirp = `-- name: SoftDeleteChirp :exec
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE (id = $1 OR rechirp_of_id = $1)
+  AND deleted_at IS NULL
+`
+
+// Rechirps of the chirp go with it, marked with the same time so restoring
+// brings back exactly those.
+func (q *Queries) SoftDeleteChirp(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, softDeleteChirp, chirpID)
+	return err
+}
+
+const tombstoneChirp = `-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', is_tombstone = true, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) TombstoneChirp(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, tombstoneChirp, id)
+	return err
+}
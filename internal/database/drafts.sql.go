@@ -0,0 +1,249 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: drafts.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const claimDueDraft = `-- name: ClaimDueDraft :one
+SELECT id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at
+FROM drafts
+WHERE publish_at <= NOW()
+ORDER BY publish_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED
+`
+
+func (q *Queries) ClaimDueDraft(ctx context.Context) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, claimDueDraft)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.QuoteOfID,
+		pq.Array(&i.AttachmentIds),
+		&i.PublishAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createDraft = `-- name: CreateDraft :one
+INSERT INTO drafts (id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at)
+VALUES (
+  gen_random_uuid(), $1, $2, $3, $4, $6::uuid[], $5, NOW(), NOW()
+)
+RETURNING id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at
+`
+
+type CreateDraftParams struct {
+	UserID        uuid.UUID
+	Body          string
+	ParentID      uuid.NullUUID
+	QuoteOfID     uuid.NullUUID
+	PublishAt     sql.NullTime
+	AttachmentIds []uuid.UUID
+}
+
+func (q *Queries) CreateDraft(ctx context.Context, arg CreateDraftParams) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, createDraft,
+		arg.UserID,
+		arg.Body,
+		arg.ParentID,
+		arg.QuoteOfID,
+		arg.PublishAt,
+		pq.Array(arg.AttachmentIds),
+	)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.QuoteOfID,
+		pq.Array(&i.AttachmentIds),
+		&i.PublishAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteDraft = `-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1
+`
+
+func (q *Queries) DeleteDraft(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteDraft, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getDraftById = `-- name: GetDraftById :one
+SELECT id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at
+FROM drafts
+WHERE id = $1
+`
+
+func (q *Queries) GetDraftById(ctx context.Context, id uuid.UUID) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, getDraftById, id)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.QuoteOfID,
+		pq.Array(&i.AttachmentIds),
+		&i.PublishAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getDrafts = `-- name: GetDrafts :many
+SELECT id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at
+FROM drafts
+WHERE user_id = $1
+  AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) < ($2::timestamp, $3::uuid)
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT $4
+`
+
+type GetDraftsParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetDrafts(ctx context.Context, arg GetDraftsParams) ([]Draft, error) {
+	rows, err := q.db.QueryContext(ctx, getDrafts,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Draft
+	for rows.Next() {
+		var i Draft
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.ParentID,
+			&i.QuoteOfID,
+			pq.Array(&i.AttachmentIds),
+			&i.PublishAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockDraft = `-- name: LockDraft :one
+SELECT id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at
+FROM drafts
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockDraft(ctx context.Context, id uuid.UUID) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, lockDraft, id)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.QuoteOfID,
+		pq.Array(&i.AttachmentIds),
+		&i.PublishAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const unscheduleDraft = `-- name: UnscheduleDraft :exec
+UPDATE drafts
+SET publish_at = NULL, updated_at = NOW()
+WHERE id = $1
+`
+
+func (q *Queries) UnscheduleDraft(ctx context.Context, id uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, unscheduleDraft, id)
+	return err
+}
+
+const updateDraft = `-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $2, parent_id = $3, quote_of_id = $4, attachment_ids = $6::uuid[], publish_at = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at
+`
+
+type UpdateDraftParams struct {
+	ID            uuid.UUID
+	Body          string
+	ParentID      uuid.NullUUID
+	QuoteOfID     uuid.NullUUID
+	PublishAt     sql.NullTime
+	AttachmentIds []uuid.UUID
+}
+
+func (q *Queries) UpdateDraft(ctx context.Context, arg UpdateDraftParams) (Draft, error) {
+	row := q.db.QueryRowContext(ctx, updateDraft,
+		arg.ID,
+		arg.Body,
+		arg.ParentID,
+		arg.QuoteOfID,
+		arg.PublishAt,
+		pq.Array(arg.AttachmentIds),
+	)
+	var i Draft
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Body,
+		&i.ParentID,
+		&i.QuoteOfID,
+		pq.Array(&i.AttachmentIds),
+		&i.PublishAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,24 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: events.sql
+
+package database
+
+import (
+	"context"
+)
+
+const notifyEvent = `-- name: NotifyEvent :exec
+SELECT pg_notify($1::text, $2::text)
+`
+
+type NotifyEventParams struct {
+	Channel string
+	Payload string
+}
+
+func (q *Queries) NotifyEvent(ctx context.Context, arg NotifyEventParams) error {
+	_, err := q.db.ExecContext(ctx, notifyEvent, arg.Channel, arg.Payload)
+	return err
+}
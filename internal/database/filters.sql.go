@@ -0,0 +1,329 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: filters.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const countMutedKeywords = `-- name: CountMutedKeywords :one
+SELECT COUNT(*)
+FROM muted_keywords
+WHERE user_id = $1
+`
+
+func (q *Queries) CountMutedKeywords(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countMutedKeywords, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const createFilterRule = `-- name: CreateFilterRule :one
+INSERT INTO filter_rules (id, phrase, action, created_at, updated_at)
+VALUES (
+  gen_random_uuid(), $1, $2, NOW(), NOW()
+)
+RETURNING id, phrase, action, created_at, updated_at
+`
+
+type CreateFilterRuleParams struct {
+	Phrase string
+	Action string
+}
+
+func (q *Queries) CreateFilterRule(ctx context.Context, arg CreateFilterRuleParams) (FilterRule, error) {
+	row := q.db.QueryRowContext(ctx, createFilterRule, arg.Phrase, arg.Action)
+	var i FilterRule
+	err := row.Scan(
+		&i.ID,
+		&i.Phrase,
+		&i.Action,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createMutedKeyword = `-- name: CreateMutedKeyword :execrows
+INSERT INTO muted_keywords (user_id, keyword, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreateMutedKeywordParams struct {
+	UserID  uuid.UUID
+	Keyword string
+}
+
+func (q *Queries) CreateMutedKeyword(ctx context.Context, arg CreateMutedKeywordParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createMutedKeyword, arg.UserID, arg.Keyword)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteChirpFlag = `-- name: DeleteChirpFlag :execrows
+DELETE FROM chirp_flags
+WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteChirpFlag(ctx context.Context, chirpID uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteChirpFlag, chirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFilterRule = `-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules
+WHERE id = $1
+`
+
+func (q *Queries) DeleteFilterRule(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFilterRule, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteMutedKeyword = `-- name: DeleteMutedKeyword :exec
+DELETE FROM muted_keywords
+WHERE user_id = $1 AND keyword = $2
+`
+
+type DeleteMutedKeywordParams struct {
+	UserID  uuid.UUID
+	Keyword string
+}
+
+func (q *Queries) DeleteMutedKeyword(ctx context.Context, arg DeleteMutedKeywordParams) error {
+	_, err := q.db.ExecContext(ctx, deleteMutedKeyword, arg.UserID, arg.Keyword)
+	return err
+}
+
+const getFilterRules = `-- name: GetFilterRules :many
+SELECT id, phrase, action, created_at, updated_at
+FROM filter_rules
+ORDER BY phrase
+`
+
+func (q *Queries) GetFilterRules(ctx context.Context) ([]FilterRule, error) {
+	rows, err := q.db.QueryContext(ctx, getFilterRules)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FilterRule
+	for rows.Next() {
+		var i FilterRule
+		if err := rows.Scan(
+			&i.ID,
+			&i.Phrase,
+			&i.Action,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFlaggedChirps = `-- name: GetFlaggedChirps :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at, f.phrases, f.created_at AS flagged_at
+FROM chirp_flags AS f
+JOIN chirps AS c ON c.id = f.chirp_id
+WHERE c.deleted_at IS NULL
+  AND NOT c.is_tombstone
+  AND (
+    $1::timestamp IS NULL
+    OR (f.created_at, c.id) > ($1::timestamp, $2::uuid)
+  )
+ORDER BY f.created_at, c.id
+LIMIT $3
+`
+
+type GetFlaggedChirpsParams struct {
+	CursorFlaggedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type GetFlaggedChirpsRow struct {
+	Chirp     Chirp
+	Phrases   []string
+	FlaggedAt time.Time
+}
+
+func (q *Queries) GetFlaggedChirps(ctx context.Context, arg GetFlaggedChirpsParams) ([]GetFlaggedChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFlaggedChirps, arg.CursorFlaggedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFlaggedChirpsRow
+	for rows.Next() {
+		var i GetFlaggedChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.ParentID,
+			&i.Chirp.IsTombstone,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.HiddenAt,
+			pq.Array(&i.Phrases),
+			&i.FlaggedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getKeywordMuterIds = `-- name: GetKeywordMuterIds :many
+SELECT DISTINCT k.user_id
+FROM muted_keywords AS k
+JOIN chirp_vectors AS v ON v.chirp_id = COALESCE(
+  (SELECT c.rechirp_of_id FROM chirps AS c WHERE c.id = $1::uuid),
+  $1::uuid
+)
+WHERE v.mute_vector @@ phraseto_tsquery('simple', k.keyword)
+`
+
+// Users with a muted keyword the chirp matches, or for a rechirp the
+// original, matched the same way as on the feeds.
+func (q *Queries) GetKeywordMuterIds(ctx context.Context, chirpID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getKeywordMuterIds, chirpID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var user_id uuid.UUID
+		if err := rows.Scan(&user_id); err != nil {
+			return nil, err
+		}
+		items = append(items, user_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getMutedKeywords = `-- name: GetMutedKeywords :many
+SELECT keyword, created_at
+FROM muted_keywords
+WHERE user_id = $1
+ORDER BY created_at, keyword
+`
+
+type GetMutedKeywordsRow struct {
+	Keyword   string
+	CreatedAt time.Time
+}
+
+func (q *Queries) GetMutedKeywords(ctx context.Context, userID uuid.UUID) ([]GetMutedKeywordsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMutedKeywords, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMutedKeywordsRow
+	for rows.Next() {
+		var i GetMutedKeywordsRow
+		if err := rows.Scan(&i.Keyword, &i.CreatedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateFilterRule = `-- name: UpdateFilterRule :one
+UPDATE filter_rules
+SET phrase = $2, action = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING id, phrase, action, created_at, updated_at
+`
+
+type UpdateFilterRuleParams struct {
+	ID     uuid.UUID
+	Phrase string
+	Action string
+}
+
+func (q *Queries) UpdateFilterRule(ctx context.Context, arg UpdateFilterRuleParams) (FilterRule, error) {
+	row := q.db.QueryRowContext(ctx, updateFilterRule, arg.ID, arg.Phrase, arg.Action)
+	var i FilterRule
+	err := row.Scan(
+		&i.ID,
+		&i.Phrase,
+		&i.Action,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const upsertChirpFlag = `-- name: UpsertChirpFlag :exec
+INSERT INTO chirp_flags (chirp_id, phrases, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT (chirp_id) DO UPDATE SET phrases = EXCLUDED.phrases
+`
+
+type UpsertChirpFlagParams struct {
+	ChirpID uuid.UUID
+	Phrases []string
+}
+
+func (q *Queries) UpsertChirpFlag(ctx context.Context, arg UpsertChirpFlagParams) error {
+	_, err := q.db.ExecContext(ctx, upsertChirpFlag, arg.ChirpID, pq.Array(arg.Phrases))
+	return err
+}
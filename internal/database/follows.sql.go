@@ -0,0 +1,342 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: follows.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const createFollow = `-- name: CreateFollow :execrows
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT $1, $2, NOW()
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM blocks AS b
+  WHERE (b.blocker_id = $1 AND b.blocked_id = $2)
+    OR (b.blocker_id = $2 AND b.blocked_id = $1)
+)
+ON CONFLICT DO NOTHING
+`
+
+type CreateFollowParams struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+}
+
+// Nothing is inserted if either user has blocked the other.
+func (q *Queries) CreateFollow(ctx context.Context, arg CreateFollowParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createFollow, arg.FollowerID, arg.FolloweeID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deleteFollow = `-- name: DeleteFollow :exec
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2
+`
+
+type DeleteFollowParams struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+}
+
+func (q *Queries) DeleteFollow(ctx context.Context, arg DeleteFollowParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFollow, arg.FollowerID, arg.FolloweeID)
+	return err
+}
+
+const deleteFollowsBetween = `-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = $1 AND followee_id = $2)
+  OR (follower_id = $2 AND followee_id = $1)
+`
+
+type DeleteFollowsBetweenParams struct {
+	UserID  uuid.UUID
+	OtherID uuid.UUID
+}
+
+func (q *Queries) DeleteFollowsBetween(ctx context.Context, arg DeleteFollowsBetweenParams) error {
+	_, err := q.db.ExecContext(ctx, deleteFollowsBetween, arg.UserID, arg.OtherID)
+	return err
+}
+
+const getFollowers = `-- name: GetFollowers :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, f.created_at AS followed_at
+FROM follows AS f
+JOIN users AS u ON u.id = f.follower_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE f.followee_id = $1
+  AND (
+    $2::timestamp IS NULL
+    OR (f.created_at, u.id) < ($2::timestamp, $3::uuid)
+  )
+ORDER BY f.created_at DESC, u.id DESC
+LIMIT $4
+`
+
+type GetFollowersParams struct {
+	UserID           uuid.UUID
+	CursorFollowedAt sql.NullTime
+	CursorID         uuid.NullUUID
+	Limit            int32
+}
+
+type GetFollowersRow struct {
+	ID          uuid.UUID
+	Username    sql.NullString
+	DisplayName string
+	AvatarKey   sql.NullString
+	IsChirpyRed bool
+	CreatedAt   time.Time
+	FollowedAt  time.Time
+}
+
+func (q *Queries) GetFollowers(ctx context.Context, arg GetFollowersParams) ([]GetFollowersRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowers,
+		arg.UserID,
+		arg.CursorFollowedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowersRow
+	for rows.Next() {
+		var i GetFollowersRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarKey,
+			&i.IsChirpyRed,
+			&i.CreatedAt,
+			&i.FollowedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowing = `-- name: GetFollowing :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, f.created_at AS followed_at
+FROM follows AS f
+JOIN users AS u ON u.id = f.followee_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE f.follower_id = $1
+  AND (
+    $2::timestamp IS NULL
+    OR (f.created_at, u.id) < ($2::timestamp, $3::uuid)
+  )
+ORDER BY f.created_at DESC, u.id DESC
+LIMIT $4
+`
+
+type GetFollowingParams struct {
+	UserID           uuid.UUID
+	CursorFollowedAt sql.NullTime
+	CursorID         uuid.NullUUID
+	Limit            int32
+}
+
+type GetFollowingRow struct {
+	ID          uuid.UUID
+	Username    sql.NullString
+	DisplayName string
+	AvatarKey   sql.NullString
+	IsChirpyRed bool
+	CreatedAt   time.Time
+	FollowedAt  time.Time
+}
+
+func (q *Queries) GetFollowing(ctx context.Context, arg GetFollowingParams) ([]GetFollowingRow, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowing,
+		arg.UserID,
+		arg.CursorFollowedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetFollowingRow
+	for rows.Next() {
+		var i GetFollowingRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.DisplayName,
+			&i.AvatarKey,
+			&i.IsChirpyRed,
+			&i.CreatedAt,
+			&i.FollowedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFollowingIds = `-- name: GetFollowingIds :many
+SELECT f.followee_id
+FROM follows AS f
+WHERE f.follower_id = $1
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = f.follower_id AND m.muted_id = f.followee_id
+  )
+`
+
+// Muted users are left out, as they are from the timeline.
+func (q *Queries) GetFollowingIds(ctx context.Context, followerID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.db.QueryContext(ctx, getFollowingIds, followerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []uuid.UUID
+	for rows.Next() {
+		var followee_id uuid.UUID
+		if err := rows.Scan(&followee_id); err != nil {
+			return nil, err
+		}
+		items = append(items, followee_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTimeline = `-- name: GetTimeline :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+WHERE NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $1)
+  AND (
+    c.user_id = $1
+    OR c.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1)
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $1
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = $1 AND m.muted_id = c.user_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = $1
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = COALESCE(c.rechirp_of_id, c.id))
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  -- Show each original once, at the position of its newest rechirp.
+  AND NOT EXISTS (
+    SELECT 1
+    FROM chirps AS newer
+    WHERE COALESCE(newer.rechirp_of_id, newer.id) = COALESCE(c.rechirp_of_id, c.id)
+      AND (newer.created_at, newer.id) > (c.created_at, c.id)
+      AND NOT newer.is_tombstone
+      AND newer.deleted_at IS NULL
+      AND (
+        newer.user_id = $1
+        OR newer.user_id IN (SELECT followee_id FROM follows WHERE follower_id = $1)
+      )
+      AND NOT EXISTS (
+        SELECT 1
+        FROM mutes AS m
+        WHERE m.muter_id = $1 AND m.muted_id = newer.user_id
+      )
+  )
+  AND (
+    $2::timestamp IS NULL
+    OR (c.created_at, c.id) < ($2::timestamp, $3::uuid)
+  )
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT $4
+`
+
+type GetTimelineParams struct {
+	UserID          uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetTimeline(ctx context.Context, arg GetTimelineParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getTimeline,
+		arg.UserID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,94 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: mentions.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createMentions = `-- name: CreateMentions :exec
+INSERT INTO mentions (chirp_id, user_id, start_index, end_index)
+SELECT
+  $1,
+  unnest($2::uuid[]),
+  unnest($3::int[]),
+  unnest($4::int[])
+`
+
+type CreateMentionsParams struct {
+	ChirpID      uuid.UUID
+	UserIds      []uuid.UUID
+	StartIndexes []int32
+	EndIndexes   []int32
+}
+
+func (q *Queries) CreateMentions(ctx context.Context, arg CreateMentionsParams) error {
+	_, err := q.db.ExecContext(ctx, createMentions,
+		arg.ChirpID,
+		pq.Array(arg.UserIds),
+		pq.Array(arg.StartIndexes),
+		pq.Array(arg.EndIndexes),
+	)
+	return err
+}
+
+const deleteMentions = `-- name: DeleteMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteMentions(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteMentions, chirpID)
+	return err
+}
+
+const getMentions = `-- name: GetMentions :many
+SELECT m.chirp_id, m.user_id, u.username::text AS username, m.start_index, m.end_index
+FROM mentions AS m
+JOIN users AS u ON u.id = m.user_id
+WHERE m.chirp_id = ANY($1::uuid[])
+ORDER BY m.chirp_id, m.start_index
+`
+
+type GetMentionsRow struct {
+	ChirpID    uuid.UUID
+	UserID     uuid.UUID
+	Username   string
+	StartIndex int32
+	EndIndex   int32
+}
+
+func (q *Queries) GetMentions(ctx context.Context, chirpIds []uuid.UUID) ([]GetMentionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getMentions, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetMentionsRow
+	for rows.Next() {
+		var i GetMentionsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.UserID,
+			&i.Username,
+			&i.StartIndex,
+			&i.EndIndex,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
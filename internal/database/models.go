@@ -11,21 +11,193 @@ import (
 	"github.com/google/uuid"
 )
 
+type Attachment struct {
+	ID           uuid.UUID
+	UserID       uuid.UUID
+	ChirpID      uuid.NullUUID
+	Position     int32
+	ContentType  string
+	SizeBytes    int32
+	Width        int32
+	Height       int32
+	StorageKey   string
+	ThumbnailKey string
+	CreatedAt    time.Time
+}
+
+type Block struct {
+	BlockerID uuid.UUID
+	BlockedID uuid.UUID
+	CreatedAt time.Time
+}
+
+type Bookmark struct {
+	UserID    uuid.UUID
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
 type Chirp struct {
+	ID          uuid.UUID
+	UserID      uuid.UUID
+	Body        string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	ParentID    uuid.NullUUID
+	IsTombstone bool
+	RechirpOfID uuid.NullUUID
+	QuoteOfID   uuid.NullUUID
+	EditedAt    sql.NullTime
+	DeletedAt   sql.NullTime
+	HiddenAt    sql.NullTime
+}
+
+type ChirpFlag struct {
+	ChirpID   uuid.UUID
+	Phrases   []string
+	CreatedAt time.Time
+}
+
+type ChirpRevision struct {
 	ID        uuid.UUID
-	UserID    uuid.UUID
+	ChirpID   uuid.UUID
 	Body      string
 	CreatedAt time.Time
+}
+
+type ChirpTag struct {
+	ChirpID   uuid.UUID
+	Tag       string
+	CreatedAt time.Time
+}
+
+type ChirpVector struct {
+	ChirpID      uuid.UUID
+	SearchVector interface{}
+	MuteVector   interface{}
+}
+
+type Draft struct {
+	ID            uuid.UUID
+	UserID        uuid.UUID
+	Body          string
+	ParentID      uuid.NullUUID
+	QuoteOfID     uuid.NullUUID
+	AttachmentIds []uuid.UUID
+	PublishAt     sql.NullTime
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+type FilterRule struct {
+	ID        uuid.UUID
+	Phrase    string
+	Action    string
+	CreatedAt time.Time
 	UpdatedAt time.Time
 }
 
-type RefreshToken struct {
-	Token     string
+type Follow struct {
+	FollowerID uuid.UUID
+	FolloweeID uuid.UUID
+	CreatedAt  time.Time
+}
+
+type Like struct {
 	UserID    uuid.UUID
+	ChirpID   uuid.UUID
 	CreatedAt time.Time
-	UpdatedAt time.Time
+}
+
+type Mention struct {
+	ChirpID    uuid.UUID
+	UserID     uuid.UUID
+	StartIndex int32
+	EndIndex   int32
+}
+
+type ModerationAction struct {
+	ID        uuid.UUID
+	Action    string
+	ReportID  uuid.NullUUID
+	ChirpID   uuid.NullUUID
+	UserID    uuid.NullUUID
+	Note      string
+	CreatedAt time.Time
+	ActorID   uuid.NullUUID
+}
+
+type Mute struct {
+	MuterID   uuid.UUID
+	MutedID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type MutedKeyword struct {
+	UserID    uuid.UUID
+	Keyword   string
+	CreatedAt time.Time
+}
+
+type Notification struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	ActorID   uuid.UUID
+	Kind      string
+	ChirpID   uuid.NullUUID
+	CreatedAt time.Time
+	ReadAt    sql.NullTime
+}
+
+type PinnedChirp struct {
+	UserID    uuid.UUID
+	Slot      int32
+	ChirpID   uuid.UUID
+	CreatedAt time.Time
+}
+
+type Poll struct {
+	ChirpID   uuid.UUID
 	ExpiresAt time.Time
-	RevokedAt sql.NullTime
+	CreatedAt time.Time
+}
+
+type PollOption struct {
+	ChirpID  uuid.UUID
+	Position int32
+	Text     string
+}
+
+type PollVote struct {
+	ChirpID   uuid.UUID
+	UserID    uuid.UUID
+	Position  int32
+	CreatedAt time.Time
+}
+
+type RefreshToken struct {
+	Token           string
+	UserID          uuid.UUID
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+	ExpiresAt       time.Time
+	RevokedAt       sql.NullTime
+	FamilyID        uuid.UUID
+	RotatedAt       sql.NullTime
+	ReuseDetectedAt sql.NullTime
+}
+
+type Report struct {
+	ID         uuid.UUID
+	ReporterID uuid.UUID
+	UserID     uuid.UUID
+	ChirpID    uuid.NullUUID
+	Reason     string
+	Details    string
+	Status     string
+	Resolution sql.NullString
+	CreatedAt  time.Time
+	ResolvedAt sql.NullTime
 }
 
 type User struct {
@@ -35,4 +207,10 @@ type User struct {
 	UpdatedAt      time.Time
 	HashedPassword string
 	IsChirpyRed    bool
+	Username       sql.NullString
+	DisplayName    string
+	Bio            string
+	AvatarID       uuid.NullUUID
+	SuspendedAt    sql.NullTime
+	Role           string
 }
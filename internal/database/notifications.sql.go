@@ -0,0 +1,191 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: notifications.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createNotifications = `-- name: CreateNotifications :many
+INSERT INTO notifications (id, user_id, actor_id, kind, chirp_id, created_at, read_at)
+SELECT gen_random_uuid(), unnest($1::uuid[]), $2, $3, $4, NOW(), NULL
+RETURNING id, user_id, actor_id, kind, chirp_id, created_at, read_at
+`
+
+type CreateNotificationsParams struct {
+	UserIds []uuid.UUID
+	ActorID uuid.UUID
+	Kind    string
+	ChirpID uuid.NullUUID
+}
+
+func (q *Queries) CreateNotifications(ctx context.Context, arg CreateNotificationsParams) ([]Notification, error) {
+	rows, err := q.db.QueryContext(ctx, createNotifications,
+		pq.Array(arg.UserIds),
+		arg.ActorID,
+		arg.Kind,
+		arg.ChirpID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Notification
+	for rows.Next() {
+		var i Notification
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.ActorID,
+			&i.Kind,
+			&i.ChirpID,
+			&i.CreatedAt,
+			&i.ReadAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getNotificationById = `-- name: GetNotificationById :one
+SELECT id, user_id, actor_id, kind, chirp_id, created_at, read_at
+FROM notifications
+WHERE id = $1
+`
+
+func (q *Queries) GetNotificationById(ctx context.Context, id uuid.UUID) (Notification, error) {
+	row := q.db.QueryRowContext(ctx, getNotificationById, id)
+	var i Notification
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.ActorID,
+		&i.Kind,
+		&i.ChirpID,
+		&i.CreatedAt,
+		&i.ReadAt,
+	)
+	return i, err
+}
+
+const getNotifications = `-- name: GetNotifications :many
+SELECT n.id, n.user_id, n.actor_id, n.kind, n.chirp_id, n.created_at, n.read_at, u.username AS actor_username
+FROM notifications AS n
+JOIN users AS u ON u.id = n.actor_id
+WHERE n.user_id = $1
+  AND (NOT $2::boolean OR n.read_at IS NULL)
+  AND (
+    $3::timestamp IS NULL
+    OR (n.created_at, n.id) < ($3::timestamp, $4::uuid)
+  )
+ORDER BY n.created_at DESC, n.id DESC
+LIMIT $5
+`
+
+type GetNotificationsParams struct {
+	UserID          uuid.UUID
+	UnreadOnly      bool
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+type GetNotificationsRow struct {
+	Notification  Notification
+	ActorUsername sql.NullString
+}
+
+func (q *Queries) GetNotifications(ctx context.Context, arg GetNotificationsParams) ([]GetNotificationsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNotifications,
+		arg.UserID,
+		arg.UnreadOnly,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNotificationsRow
+	for rows.Next() {
+		var i GetNotificationsRow
+		if err := rows.Scan(
+			&i.Notification.ID,
+			&i.Notification.UserID,
+			&i.Notification.ActorID,
+			&i.Notification.Kind,
+			&i.Notification.ChirpID,
+			&i.Notification.CreatedAt,
+			&i.Notification.ReadAt,
+			&i.ActorUsername,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadNotificationCount = `-- name: GetUnreadNotificationCount :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) GetUnreadNotificationCount(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, getUnreadNotificationCount, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const markAllNotificationsRead = `-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL
+`
+
+func (q *Queries) MarkAllNotificationsRead(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, markAllNotificationsRead, userID)
+	return err
+}
+
+const markNotificationsRead = `-- name: MarkNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1
+  AND read_at IS NULL
+  AND id = ANY($2::uuid[])
+`
+
+type MarkNotificationsReadParams struct {
+	UserID uuid.UUID
+	Ids    []uuid.UUID
+}
+
+func (q *Queries) MarkNotificationsRead(ctx context.Context, arg MarkNotificationsReadParams) error {
+	_, err := q.db.ExecContext(ctx, markNotificationsRead, arg.UserID, pq.Array(arg.Ids))
+	return err
+}
@@ -0,0 +1,109 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: pinned_chirps.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const getPinnedChirps = `-- name: GetPinnedChirps :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM pinned_chirps AS p
+JOIN chirps AS c ON c.id = p.chirp_id
+WHERE p.user_id = $1
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $2)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $2
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+ORDER BY p.created_at DESC
+`
+
+type GetPinnedChirpsParams struct {
+	UserID   uuid.UUID
+	ViewerID uuid.UUID
+}
+
+func (q *Queries) GetPinnedChirps(ctx context.Context, arg GetPinnedChirpsParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getPinnedChirps, arg.UserID, arg.ViewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const pinChirp = `-- name: PinChirp :execrows
+INSERT INTO pinned_chirps (user_id, slot, chirp_id, created_at)
+SELECT $1, MIN(s.slot), $2, NOW()
+FROM generate_series(0, 2) AS s(slot)
+WHERE s.slot NOT IN (SELECT p.slot FROM pinned_chirps AS p WHERE p.user_id = $1)
+HAVING MIN(s.slot) IS NOT NULL
+ON CONFLICT (user_id, chirp_id) DO NOTHING
+`
+
+type PinChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+// Takes the first free slot. No rows are inserted if the chirp is already
+// pinned or every slot is taken.
+func (q *Queries) PinChirp(ctx context.Context, arg PinChirpParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, pinChirp, arg.UserID, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const unpinChirp = `-- name: UnpinChirp :exec
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2
+`
+
+type UnpinChirpParams struct {
+	UserID  uuid.UUID
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) UnpinChirp(ctx context.Context, arg UnpinChirpParams) error {
+	_, err := q.db.ExecContext(ctx, unpinChirp, arg.UserID, arg.ChirpID)
+	return err
+}
@@ -0,0 +1,173 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: polls.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createPoll = `-- name: CreatePoll :exec
+WITH poll AS (
+  INSERT INTO polls (chirp_id, expires_at, created_at)
+  VALUES ($3, $4, NOW())
+  RETURNING chirp_id
+)
+INSERT INTO poll_options (chirp_id, position, text)
+SELECT
+  (SELECT chirp_id FROM poll),
+  unnest($1::int[]),
+  unnest($2::text[])
+`
+
+type CreatePollParams struct {
+	Positions []int32
+	Options   []string
+	ChirpID   uuid.UUID
+	ExpiresAt time.Time
+}
+
+func (q *Queries) CreatePoll(ctx context.Context, arg CreatePollParams) error {
+	_, err := q.db.ExecContext(ctx, createPoll,
+		pq.Array(arg.Positions),
+		pq.Array(arg.Options),
+		arg.ChirpID,
+		arg.ExpiresAt,
+	)
+	return err
+}
+
+const createPollVote = `-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, position, created_at)
+SELECT p.chirp_id, $1, $2, NOW()
+FROM polls AS p
+JOIN chirps AS c ON c.id = p.chirp_id
+WHERE p.chirp_id = $3
+  AND p.expires_at > NOW()
+  AND c.deleted_at IS NULL
+ON CONFLICT (chirp_id, user_id) DO NOTHING
+`
+
+type CreatePollVoteParams struct {
+	UserID   uuid.UUID
+	Position int32
+	ChirpID  uuid.UUID
+}
+
+func (q *Queries) CreatePollVote(ctx context.Context, arg CreatePollVoteParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createPollVote, arg.UserID, arg.Position, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const deletePoll = `-- name: DeletePoll :exec
+DELETE FROM polls
+WHERE chirp_id = $1
+`
+
+func (q *Queries) DeletePoll(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deletePoll, chirpID)
+	return err
+}
+
+const getPollOptions = `-- name: GetPollOptions :many
+SELECT o.chirp_id, o.position, o.text, COUNT(v.user_id) AS vote_count
+FROM poll_options AS o
+LEFT JOIN poll_votes AS v ON v.chirp_id = o.chirp_id AND v.position = o.position
+WHERE o.chirp_id = ANY($1::uuid[])
+GROUP BY o.chirp_id, o.position
+ORDER BY o.chirp_id, o.position
+`
+
+type GetPollOptionsRow struct {
+	ChirpID   uuid.UUID
+	Position  int32
+	Text      string
+	VoteCount int64
+}
+
+func (q *Queries) GetPollOptions(ctx context.Context, chirpIds []uuid.UUID) ([]GetPollOptionsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPollOptions, pq.Array(chirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollOptionsRow
+	for rows.Next() {
+		var i GetPollOptionsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.Position,
+			&i.Text,
+			&i.VoteCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPolls = `-- name: GetPolls :many
+SELECT p.chirp_id, p.expires_at, p.expires_at <= NOW() AS closed, v.position AS voted_position
+FROM polls AS p
+JOIN chirps AS c ON c.id = p.chirp_id
+LEFT JOIN poll_votes AS v ON v.chirp_id = p.chirp_id AND v.user_id = $1
+WHERE p.chirp_id = ANY($2::uuid[])
+  AND c.deleted_at IS NULL
+`
+
+type GetPollsParams struct {
+	ViewerID uuid.UUID
+	ChirpIds []uuid.UUID
+}
+
+type GetPollsRow struct {
+	ChirpID       uuid.UUID
+	ExpiresAt     time.Time
+	Closed        bool
+	VotedPosition sql.NullInt32
+}
+
+func (q *Queries) GetPolls(ctx context.Context, arg GetPollsParams) ([]GetPollsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPolls, arg.ViewerID, pq.Array(arg.ChirpIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPollsRow
+	for rows.Next() {
+		var i GetPollsRow
+		if err := rows.Scan(
+			&i.ChirpID,
+			&i.ExpiresAt,
+			&i.Closed,
+			&i.VotedPosition,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,303 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: reports.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+)
+
+const createModerationAction = `-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, actor_id, action, report_id, chirp_id, user_id, note, created_at)
+VALUES (
+  gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW()
+)
+RETURNING id, action, report_id, chirp_id, user_id, note, created_at, actor_id
+`
+
+type CreateModerationActionParams struct {
+	ActorID  uuid.NullUUID
+	Action   string
+	ReportID uuid.NullUUID
+	ChirpID  uuid.NullUUID
+	UserID   uuid.NullUUID
+	Note     string
+}
+
+func (q *Queries) CreateModerationAction(ctx context.Context, arg CreateModerationActionParams) (ModerationAction, error) {
+	row := q.db.QueryRowContext(ctx, createModerationAction,
+		arg.ActorID,
+		arg.Action,
+		arg.ReportID,
+		arg.ChirpID,
+		arg.UserID,
+		arg.Note,
+	)
+	var i ModerationAction
+	err := row.Scan(
+		&i.ID,
+		&i.Action,
+		&i.ReportID,
+		&i.ChirpID,
+		&i.UserID,
+		&i.Note,
+		&i.CreatedAt,
+		&i.ActorID,
+	)
+	return i, err
+}
+
+const createReport = `-- name: CreateReport :one
+INSERT INTO reports (id, reporter_id, user_id, chirp_id, reason, details, status, created_at)
+VALUES (
+  gen_random_uuid(), $1, $2, $3, $4, $5, 'open', NOW()
+)
+RETURNING id, reporter_id, user_id, chirp_id, reason, details, status, resolution, created_at, resolved_at
+`
+
+type CreateReportParams struct {
+	ReporterID uuid.UUID
+	UserID     uuid.UUID
+	ChirpID    uuid.NullUUID
+	Reason     string
+	Details    string
+}
+
+func (q *Queries) CreateReport(ctx context.Context, arg CreateReportParams) (Report, error) {
+	row := q.db.QueryRowContext(ctx, createReport,
+		arg.ReporterID,
+		arg.UserID,
+		arg.ChirpID,
+		arg.Reason,
+		arg.Details,
+	)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.ReporterID,
+		&i.UserID,
+		&i.ChirpID,
+		&i.Reason,
+		&i.Details,
+		&i.Status,
+		&i.Resolution,
+		&i.CreatedAt,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const getModerationActions = `-- name: GetModerationActions :many
+SELECT id, action, report_id, chirp_id, user_id, note, created_at, actor_id
+FROM moderation_actions
+WHERE $1::timestamp IS NULL
+  OR (created_at, id) < ($1::timestamp, $2::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT $3
+`
+
+type GetModerationActionsParams struct {
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetModerationActions(ctx context.Context, arg GetModerationActionsParams) ([]ModerationAction, error) {
+	rows, err := q.db.QueryContext(ctx, getModerationActions, arg.CursorCreatedAt, arg.CursorID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ModerationAction
+	for rows.Next() {
+		var i ModerationAction
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.ReportID,
+			&i.ChirpID,
+			&i.UserID,
+			&i.Note,
+			&i.CreatedAt,
+			&i.ActorID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getReportById = `-- name: GetReportById :one
+SELECT id, reporter_id, user_id, chirp_id, reason, details, status, resolution, created_at, resolved_at
+FROM reports
+WHERE id = $1
+`
+
+func (q *Queries) GetReportById(ctx context.Context, id uuid.UUID) (Report, error) {
+	row := q.db.QueryRowContext(ctx, getReportById, id)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.ReporterID,
+		&i.UserID,
+		&i.ChirpID,
+		&i.Reason,
+		&i.Details,
+		&i.Status,
+		&i.Resolution,
+		&i.CreatedAt,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const getReports = `-- name: GetReports :many
+SELECT id, reporter_id, user_id, chirp_id, reason, details, status, resolution, created_at, resolved_at
+FROM reports
+WHERE status = $1
+  AND (
+    $2::timestamp IS NULL
+    OR (created_at, id) > ($2::timestamp, $3::uuid)
+  )
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type GetReportsParams struct {
+	Status          string
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+func (q *Queries) GetReports(ctx context.Context, arg GetReportsParams) ([]Report, error) {
+	rows, err := q.db.QueryContext(ctx, getReports,
+		arg.Status,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Report
+	for rows.Next() {
+		var i Report
+		if err := rows.Scan(
+			&i.ID,
+			&i.ReporterID,
+			&i.UserID,
+			&i.ChirpID,
+			&i.Reason,
+			&i.Details,
+			&i.Status,
+			&i.Resolution,
+			&i.CreatedAt,
+			&i.ResolvedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const resolveReport = `-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved', resolution = $2, resolved_at = NOW()
+WHERE id = $1
+  AND status = 'open'
+RETURNING id, reporter_id, user_id, chirp_id, reason, details, status, resolution, created_at, resolved_at
+`
+
+type ResolveReportParams struct {
+	ID         uuid.UUID
+	Resolution sql.NullString
+}
+
+func (q *Queries) ResolveReport(ctx context.Context, arg ResolveReportParams) (Report, error) {
+	row := q.db.QueryRowContext(ctx, resolveReport, arg.ID, arg.Resolution)
+	var i Report
+	err := row.Scan(
+		&i.ID,
+		&i.ReporterID,
+		&i.UserID,
+		&i.ChirpID,
+		&i.Reason,
+		&i.Details,
+		&i.Status,
+		&i.Resolution,
+		&i.CreatedAt,
+		&i.ResolvedAt,
+	)
+	return i, err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const setChirpHidden = `-- name: SetChirpHidden :execrows
+UPDATE chirps
+SET hidden_at = CASE WHEN $1::boolean THEN NOW() END
+WHERE id = $2
+`
+
+type SetChirpHiddenParams struct {
+	Hidden  bool
+	ChirpID uuid.UUID
+}
+
+func (q *Queries) SetChirpHidden(ctx context.Context, arg SetChirpHiddenParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setChirpHidden, arg.Hidden, arg.ChirpID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const setUserSuspended = `-- name: SetUserSuspended :execrows
+UPDATE users
+SET suspended_at = CASE WHEN $1::boolean THEN NOW() END, updated_at = NOW()
+WHERE id = $2
+`
+
+type SetUserSuspendedParams struct {
+	Suspended bool
+	UserID    uuid.UUID
+}
+
+func (q *Queries) SetUserSuspended(ctx context.Context, arg SetUserSuspendedParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserSuspended, arg.Suspended, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,106 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: search.sql
+
+package database
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+const searchChirps = `-- name: SearchChirps :many
+SELECT
+  c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at,
+  ts_headline(
+    'english', c.body, query,
+    'StartSel=' || chr(1) || ', StopSel=' || chr(2) || ', MaxFragments=2, MaxWords=20, MinWords=5'
+  )::text AS snippet,
+  ts_rank(v.search_vector, query)::real AS rank
+FROM chirps AS c
+JOIN chirp_vectors AS v ON v.chirp_id = c.id
+CROSS JOIN websearch_to_tsquery('english', $1) AS query
+WHERE v.search_vector @@ query
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $2)
+  AND ($3::uuid IS NULL OR c.user_id = $3)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $2
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = $2
+      AND v.mute_vector @@ phraseto_tsquery('simple', k.keyword)
+  )
+ORDER BY rank DESC, c.created_at DESC, c.id DESC
+LIMIT $5
+OFFSET $4
+`
+
+type SearchChirpsParams struct {
+	Query    string
+	ViewerID uuid.UUID
+	AuthorID uuid.NullUUID
+	Offset   int32
+	Limit    int32
+}
+
+type SearchChirpsRow struct {
+	Chirp   Chirp
+	Snippet string
+	Rank    float32
+}
+
+// Leaves out chirps by users who blocked the viewer, chirps with the viewer's
+// muted keywords and chirps hidden by moderators, unless the viewer wrote
+// them.
+func (q *Queries) SearchChirps(ctx context.Context, arg SearchChirpsParams) ([]SearchChirpsRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchChirps,
+		arg.Query,
+		arg.ViewerID,
+		arg.AuthorID,
+		arg.Offset,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []SearchChirpsRow
+	for rows.Next() {
+		var i SearchChirpsRow
+		if err := rows.Scan(
+			&i.Chirp.ID,
+			&i.Chirp.UserID,
+			&i.Chirp.Body,
+			&i.Chirp.CreatedAt,
+			&i.Chirp.UpdatedAt,
+			&i.Chirp.ParentID,
+			&i.Chirp.IsTombstone,
+			&i.Chirp.RechirpOfID,
+			&i.Chirp.QuoteOfID,
+			&i.Chirp.EditedAt,
+			&i.Chirp.DeletedAt,
+			&i.Chirp.HiddenAt,
+			&i.Snippet,
+			&i.Rank,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
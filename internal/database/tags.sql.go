@@ -0,0 +1,181 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.27.0
+// source: tags.sql
+
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const createChirpTags = `-- name: CreateChirpTags :exec
+INSERT INTO chirp_tags (chirp_id, tag, created_at)
+SELECT c.id, unnest($1::text[]), c.created_at
+FROM chirps AS c
+WHERE c.id = $2
+ON CONFLICT DO NOTHING
+`
+
+type CreateChirpTagsParams struct {
+	Tags    []string
+	ChirpID uuid.UUID
+}
+
+// Tags are dated with the chirp rather than when they're saved, so editing an
+// old chirp doesn't count towards what's trending now.
+func (q *Queries) CreateChirpTags(ctx context.Context, arg CreateChirpTagsParams) error {
+	_, err := q.db.ExecContext(ctx, createChirpTags, pq.Array(arg.Tags), arg.ChirpID)
+	return err
+}
+
+const deleteChirpTags = `-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1
+`
+
+func (q *Queries) DeleteChirpTags(ctx context.Context, chirpID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, deleteChirpTags, chirpID)
+	return err
+}
+
+const getChirpsByTag = `-- name: GetChirpsByTag :many
+SELECT c.id, c.user_id, c.body, c.created_at, c.updated_at, c.parent_id, c.is_tombstone, c.rechirp_of_id, c.quote_of_id, c.edited_at, c.deleted_at, c.hidden_at
+FROM chirps AS c
+JOIN chirp_tags AS t ON t.chirp_id = c.id
+WHERE t.tag = $1
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = $2)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = $2
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = $2
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = c.id)
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  AND (
+    $3::timestamp IS NULL
+    OR (c.created_at, c.id) < ($3::timestamp, $4::uuid)
+  )
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT $5
+`
+
+type GetChirpsByTagParams struct {
+	Tag             string
+	ViewerID        uuid.UUID
+	CursorCreatedAt sql.NullTime
+	CursorID        uuid.NullUUID
+	Limit           int32
+}
+
+// Leaves out chirps by users who blocked the viewer, chirps with the viewer's
+// muted keywords and chirps hidden by moderators, unless the viewer wrote
+// them.
+func (q *Queries) GetChirpsByTag(ctx context.Context, arg GetChirpsByTagParams) ([]Chirp, error) {
+	rows, err := q.db.QueryContext(ctx, getChirpsByTag,
+		arg.Tag,
+		arg.ViewerID,
+		arg.CursorCreatedAt,
+		arg.CursorID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Chirp
+	for rows.Next() {
+		var i Chirp
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Body,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.ParentID,
+			&i.IsTombstone,
+			&i.RechirpOfID,
+			&i.QuoteOfID,
+			&i.EditedAt,
+			&i.DeletedAt,
+			&i.HiddenAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getTrendingTags = `-- name: GetTrendingTags :many
+WITH counts AS (
+  SELECT
+    t.tag,
+    COUNT(*) FILTER (WHERE t.created_at >= NOW() - make_interval(secs => $2::float8)) AS recent_count,
+    COUNT(*) FILTER (WHERE t.created_at < NOW() - make_interval(secs => $2::float8)) AS previous_count
+  FROM chirp_tags AS t
+  JOIN chirps AS c ON c.id = t.chirp_id
+  WHERE t.created_at >= NOW() - 2 * make_interval(secs => $2::float8)
+    AND c.deleted_at IS NULL
+  GROUP BY t.tag
+)
+SELECT tag, recent_count, previous_count
+FROM counts
+WHERE recent_count > 0
+ORDER BY recent_count - previous_count DESC, recent_count DESC, tag
+LIMIT $1
+`
+
+type GetTrendingTagsParams struct {
+	Limit         int32
+	WindowSeconds float64
+}
+
+type GetTrendingTagsRow struct {
+	Tag           string
+	RecentCount   int64
+	PreviousCount int64
+}
+
+// Compares each tag's uses in the latest window with the window before it,
+// so tags that are picking up speed rank above ones that are merely common.
+func (q *Queries) GetTrendingTags(ctx context.Context, arg GetTrendingTagsParams) ([]GetTrendingTagsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTrendingTags, arg.Limit, arg.WindowSeconds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTrendingTagsRow
+	for rows.Next() {
+		var i GetTrendingTagsRow
+		if err := rows.Scan(&i.Tag, &i.RecentCount, &i.PreviousCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
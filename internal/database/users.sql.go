@@ -7,27 +7,65 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
+const bootstrapAdmin = `-- name: BootstrapAdmin :execrows
+UPDATE users
+SET role = 'admin', updated_at = NOW()
+WHERE users.email = $1
+  AND NOT EXISTS (SELECT 1 FROM users AS a WHERE a.role = 'admin')
+`
+
+// Promotes the user with this email, but only while there are no admins, so
+// it can't be used to take over an installation that's already set up.
+func (q *Queries) BootstrapAdmin(ctx context.Context, email string) (int64, error) {
+	result, err := q.db.ExecContext(ctx, bootstrapAdmin, email)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const countAdmins = `-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE role = 'admin'
+`
+
+func (q *Queries) CountAdmins(ctx context.Context) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countAdmins)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createRefreshToken = `-- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, user_id, created_at, updated_at, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, user_id, family_id, created_at, updated_at, expires_at, revoked_at)
 VALUES (
-  $1, $2, NOW(), NOW(), $3, NULL
+  $1, $2, $3, NOW(), NOW(), $4, NULL
 )
-RETURNING token, user_id, created_at, updated_at, expires_at, revoked_at
+RETURNING token, user_id, created_at, updated_at, expires_at, revoked_at, family_id, rotated_at, reuse_detected_at
 `
 
 type CreateRefreshTokenParams struct {
 	Token     string
 	UserID    uuid.UUID
+	FamilyID  uuid.UUID
 	ExpiresAt time.Time
 }
 
 func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, createRefreshToken, arg.Token, arg.UserID, arg.ExpiresAt)
+	row := q.db.QueryRowContext(ctx, createRefreshToken,
+		arg.Token,
+		arg.UserID,
+		arg.FamilyID,
+		arg.ExpiresAt,
+	)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -36,25 +74,29 @@ func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshToken
 		&i.UpdatedAt,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.ReuseDetectedAt,
 	)
 	return i, err
 }
 
 const createUser = `-- name: CreateUser :one
-INSERT INTO users (id, email, created_at, updated_at, hashed_password)
+INSERT INTO users (id, email, created_at, updated_at, hashed_password, username)
 VALUES (
-  gen_random_uuid(), $1, NOW(), NOW(), $2
+  gen_random_uuid(), $1, NOW(), NOW(), $2, $3
 )
-RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, username, display_name, bio, avatar_id, suspended_at, role
 `
 
 type CreateUserParams struct {
 	Email          string
 	HashedPassword string
+	Username       sql.NullString
 }
 
 func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword)
+	row := q.db.QueryRowContext(ctx, createUser, arg.Email, arg.HashedPassword, arg.Username)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -63,12 +105,41 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarID,
+		&i.SuspendedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const getRefreshToken = `-- name: GetRefreshToken :one
+SELECT token, user_id, created_at, updated_at, expires_at, revoked_at, family_id, rotated_at, reuse_detected_at
+FROM refresh_tokens
+WHERE token = $1
+`
+
+func (q *Queries) GetRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, getRefreshToken, token)
+	var i RefreshToken
+	err := row.Scan(
+		&i.Token,
+		&i.UserID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.ExpiresAt,
+		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.ReuseDetectedAt,
 	)
 	return i, err
 }
 
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red
+SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red, username, display_name, bio, avatar_id, suspended_at, role
 FROM users
 WHERE email = $1
 `
@@ -83,12 +154,18 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarID,
+		&i.SuspendedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
 const getUserById = `-- name: GetUserById :one
-SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red
+SELECT id, email, created_at, updated_at, hashed_password, is_chirpy_red, username, display_name, bio, avatar_id, suspended_at, role
 FROM users
 WHERE id = $1
 `
@@ -103,42 +180,157 @@ func (q *Queries) GetUserById(ctx context.Context, id uuid.UUID) (User, error) {
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarID,
+		&i.SuspendedAt,
+		&i.Role,
 	)
 	return i, err
 }
 
-const getUserFromRefreshToken = `-- name: GetUserFromRefreshToken :one
-SELECT u.id, u.email, u.created_at, u.updated_at, u.hashed_password, u.is_chirpy_red
+const getUserProfile = `-- name: GetUserProfile :one
+SELECT
+  u.id, u.email, u.created_at, u.updated_at, u.hashed_password, u.is_chirpy_red, u.username, u.display_name, u.bio, u.avatar_id, u.suspended_at, u.role,
+  a.thumbnail_key AS avatar_key,
+  (SELECT COUNT(*) FROM follows AS f WHERE f.followee_id = u.id) AS follower_count,
+  (SELECT COUNT(*) FROM follows AS f WHERE f.follower_id = u.id) AS following_count,
+  (
+    SELECT COUNT(*)
+    FROM chirps AS c
+    WHERE c.user_id = u.id
+      AND c.rechirp_of_id IS NULL
+      AND NOT c.is_tombstone
+      AND c.deleted_at IS NULL
+  ) AS chirp_count
 FROM users AS u
-JOIN refresh_tokens AS r ON u.id = r.user_id
-WHERE r.token = $1
-  AND r.revoked_at IS NULL
-  AND r.expires_at > NOW()
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE LOWER(u.username) = LOWER($1)
 `
 
-func (q *Queries) GetUserFromRefreshToken(ctx context.Context, token string) (User, error) {
-	row := q.db.QueryRowContext(ctx, getUserFromRefreshToken, token)
-	var i User
+type GetUserProfileRow struct {
+	User           User
+	AvatarKey      sql.NullString
+	FollowerCount  int64
+	FollowingCount int64
+	ChirpCount     int64
+}
+
+func (q *Queries) GetUserProfile(ctx context.Context, username string) (GetUserProfileRow, error) {
+	row := q.db.QueryRowContext(ctx, getUserProfile, username)
+	var i GetUserProfileRow
 	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-		&i.HashedPassword,
-		&i.IsChirpyRed,
+		&i.User.ID,
+		&i.User.Email,
+		&i.User.CreatedAt,
+		&i.User.UpdatedAt,
+		&i.User.HashedPassword,
+		&i.User.IsChirpyRed,
+		&i.User.Username,
+		&i.User.DisplayName,
+		&i.User.Bio,
+		&i.User.AvatarID,
+		&i.User.SuspendedAt,
+		&i.User.Role,
+		&i.AvatarKey,
+		&i.FollowerCount,
+		&i.FollowingCount,
+		&i.ChirpCount,
 	)
 	return i, err
 }
 
-const revokeRefreshToken = `-- name: RevokeRefreshToken :one
+const getUsersByUsernames = `-- name: GetUsersByUsernames :many
+SELECT u.id, u.username::text AS username
+FROM users AS u
+WHERE LOWER(u.username) = ANY($1::text[])
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocker_id = u.id AND b.blocked_id = $2
+  )
+`
+
+type GetUsersByUsernamesParams struct {
+	Usernames []string
+	AuthorID  uuid.UUID
+}
+
+type GetUsersByUsernamesRow struct {
+	ID       uuid.UUID
+	Username string
+}
+
+// Users who have blocked author_id are left out, so they can't be mentioned
+// by them.
+func (q *Queries) GetUsersByUsernames(ctx context.Context, arg GetUsersByUsernamesParams) ([]GetUsersByUsernamesRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUsersByUsernames, pq.Array(arg.Usernames), arg.AuthorID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUsersByUsernamesRow
+	for rows.Next() {
+		var i GetUsersByUsernamesRow
+		if err := rows.Scan(&i.ID, &i.Username); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const revokeRefreshToken = `-- name: RevokeRefreshToken :exec
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = (SELECT r.family_id FROM refresh_tokens AS r WHERE r.token = $1)
+  AND revoked_at IS NULL
+`
+
+// Signs out of the session the token belongs to, including any tokens it
+// was rotated into.
+func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshToken, token)
+	return err
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE refresh_tokens
+SET
+  revoked_at = COALESCE(revoked_at, NOW()),
+  reuse_detected_at = NOW(),
+  updated_at = NOW()
+WHERE family_id = $1
+`
+
+// Ends a session whose rotated token was presented again, flagging it so
+// the reuse is on record.
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID uuid.UUID) error {
+	_, err := q.db.ExecContext(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const rotateRefreshToken = `-- name: RotateRefreshToken :one
+UPDATE refresh_tokens
+SET rotated_at = NOW(), updated_at = NOW()
 WHERE token = $1
-RETURNING token, user_id, created_at, updated_at, expires_at, revoked_at
+  AND rotated_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
+RETURNING token, user_id, created_at, updated_at, expires_at, revoked_at, family_id, rotated_at, reuse_detected_at
 `
 
-func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
-	row := q.db.QueryRowContext(ctx, revokeRefreshToken, token)
+// Marks a live token as used. Only one caller can rotate a given token, so
+// a second attempt finds nothing and is treated as reuse.
+func (q *Queries) RotateRefreshToken(ctx context.Context, token string) (RefreshToken, error) {
+	row := q.db.QueryRowContext(ctx, rotateRefreshToken, token)
 	var i RefreshToken
 	err := row.Scan(
 		&i.Token,
@@ -147,25 +339,103 @@ func (q *Queries) RevokeRefreshToken(ctx context.Context, token string) (Refresh
 		&i.UpdatedAt,
 		&i.ExpiresAt,
 		&i.RevokedAt,
+		&i.FamilyID,
+		&i.RotatedAt,
+		&i.ReuseDetectedAt,
 	)
 	return i, err
 }
 
+const setUserRole = `-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetUserRoleParams struct {
+	ID   uuid.UUID
+	Role string
+}
+
+func (q *Queries) SetUserRole(ctx context.Context, arg SetUserRoleParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, setUserRole, arg.ID, arg.Role)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const updateUser = `-- name: UpdateUser :one
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = NOW()
-WHERE id = $3
-RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red
+SET
+  email = $1,
+  hashed_password = $2,
+  username = COALESCE($3, username),
+  updated_at = NOW()
+WHERE id = $4
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, username, display_name, bio, avatar_id, suspended_at, role
 `
 
 type UpdateUserParams struct {
 	Email          string
 	HashedPassword string
+	Username       sql.NullString
 	ID             uuid.UUID
 }
 
 func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUser, arg.Email, arg.HashedPassword, arg.ID)
+	row := q.db.QueryRowContext(ctx, updateUser,
+		arg.Email,
+		arg.HashedPassword,
+		arg.Username,
+		arg.ID,
+	)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.HashedPassword,
+		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarID,
+		&i.SuspendedAt,
+		&i.Role,
+	)
+	return i, err
+}
+
+const updateUserProfile = `-- name: UpdateUserProfile :one
+UPDATE users
+SET
+  username = $2,
+  display_name = $3,
+  bio = $4,
+  avatar_id = $5,
+  updated_at = NOW()
+WHERE id = $1
+RETURNING id, email, created_at, updated_at, hashed_password, is_chirpy_red, username, display_name, bio, avatar_id, suspended_at, role
+`
+
+type UpdateUserProfileParams struct {
+	ID          uuid.UUID
+	Username    sql.NullString
+	DisplayName string
+	Bio         string
+	AvatarID    uuid.NullUUID
+}
+
+func (q *Queries) UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserProfile,
+		arg.ID,
+		arg.Username,
+		arg.DisplayName,
+		arg.Bio,
+		arg.AvatarID,
+	)
 	var i User
 	err := row.Scan(
 		&i.ID,
@@ -174,6 +444,12 @@ func (q *Queries) UpdateUser(ctx context.Context, arg UpdateUserParams) (User, e
 		&i.UpdatedAt,
 		&i.HashedPassword,
 		&i.IsChirpyRed,
+		&i.Username,
+		&i.DisplayName,
+		&i.Bio,
+		&i.AvatarID,
+		&i.SuspendedAt,
+		&i.Role,
 	)
 	return i, err
 }
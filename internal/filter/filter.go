@@ -0,0 +1,226 @@
+// Package filter matches chirp bodies against lists of words and phrases.
+//
+// Bodies and rule phrases are split into words at anything that isn't a
+// letter, digit or combining mark, so punctuation around a word doesn't hide
+// it: a rule for "kerfuffle" matches "Kerfuffle!" and "(kerfuffle)" but not
+// "kerfuffles".
+package filter
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Mask is what masked words are replaced with.
+const Mask = "****"
+
+// Action is what happens to a chirp that matches a rule.
+type Action string
+
+const (
+	// ActionMask replaces the matching words with Mask.
+	ActionMask Action = "mask"
+	// ActionReject refuses the chirp.
+	ActionReject Action = "reject"
+	// ActionFlag lets the chirp through but marks it for review.
+	ActionFlag Action = "flag"
+)
+
+// Valid reports whether a is one of the known actions.
+func (a Action) Valid() bool {
+	switch a {
+	case ActionMask, ActionReject, ActionFlag:
+		return true
+	}
+
+	return false
+}
+
+// Rule is a word or phrase and what to do when it's found.
+type Rule struct {
+	Phrase string
+	Action Action
+}
+
+// Token is a word in a body. Start and End are byte offsets, with End
+// exclusive.
+type Token struct {
+	Text  string
+	Start int
+	End   int
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+// Tokenize splits s into words, in the order they appear.
+func Tokenize(s string) []Token {
+	var tokens []Token
+
+	start := -1
+	for i, r := range s {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+
+		if start >= 0 {
+			tokens = append(tokens, Token{Text: s[start:i], Start: start, End: i})
+			start = -1
+		}
+	}
+
+	if start >= 0 {
+		tokens = append(tokens, Token{Text: s[start:], Start: start, End: len(s)})
+	}
+
+	return tokens
+}
+
+// Normalize returns phrase as the lowercased words it matches, separated by
+// single spaces, or "" if it has no words. Phrases that normalize the same
+// match the same text.
+func Normalize(phrase string) string {
+	tokens := Tokenize(phrase)
+
+	words := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		words = append(words, strings.ToLower(token.Text))
+	}
+
+	return strings.Join(words, " ")
+}
+
+type compiledRule struct {
+	phrase string
+	words  []string
+	action Action
+}
+
+// Filter checks bodies against a fixed set of rules. It's safe for
+// concurrent use.
+type Filter struct {
+	// Rules keyed by their first word, so each word in a body is only
+	// compared with the rules that could start there.
+	rules map[string][]compiledRule
+}
+
+// New compiles rules into a Filter. Rules without any words or with an
+// unknown action are ignored.
+func New(rules []Rule) *Filter {
+	f := &Filter{rules: make(map[string][]compiledRule, len(rules))}
+
+	for _, rule := range rules {
+		if !rule.Action.Valid() {
+			continue
+		}
+
+		phrase := Normalize(rule.Phrase)
+		if phrase == "" {
+			continue
+		}
+
+		words := strings.Split(phrase, " ")
+		f.rules[words[0]] = append(f.rules[words[0]], compiledRule{
+			phrase: phrase,
+			words:  words,
+			action: rule.Action,
+		})
+	}
+
+	return f
+}
+
+// Result is the outcome of filtering a body.
+type Result struct {
+	// Body with the words matched by mask rules replaced with Mask.
+	Body string
+	// Rejected is set if any reject rule matched.
+	Rejected bool
+	// Flagged lists the phrases of the flag rules that matched, each once.
+	Flagged []string
+}
+
+// Apply checks body against every rule.
+func (f *Filter) Apply(body string) Result {
+	tokens := Tokenize(body)
+
+	words := make([]string, len(tokens))
+	for i, token := range tokens {
+		words[i] = strings.ToLower(token.Text)
+	}
+
+	var result Result
+	var masked [][2]int
+	flagged := map[string]struct{}{}
+
+	for i, word := range words {
+		for _, rule := range f.rules[word] {
+			if !hasWordsAt(words, i, rule.words) {
+				continue
+			}
+
+			switch rule.action {
+			case ActionMask:
+				masked = append(masked, [2]int{tokens[i].Start, tokens[i+len(rule.words)-1].End})
+			case ActionReject:
+				result.Rejected = true
+			case ActionFlag:
+				if _, ok := flagged[rule.phrase]; !ok {
+					flagged[rule.phrase] = struct{}{}
+					result.Flagged = append(result.Flagged, rule.phrase)
+				}
+			}
+		}
+	}
+
+	result.Body = mask(body, masked)
+
+	return result
+}
+
+func hasWordsAt(words []string, i int, phrase []string) bool {
+	if i+len(phrase) > len(words) {
+		return false
+	}
+
+	for j, word := range phrase {
+		if words[i+j] != word {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mask replaces the byte ranges in spans, which are in order of their start,
+// with Mask. Overlapping ranges are masked together.
+func mask(body string, spans [][2]int) string {
+	if len(spans) == 0 {
+		return body
+	}
+
+	var b strings.Builder
+	b.Grow(len(body))
+
+	last := 0
+	for _, span := range spans {
+		if span[1] <= last {
+			continue
+		}
+
+		if span[0] >= last {
+			b.WriteString(body[last:span[0]])
+			b.WriteString(Mask)
+		}
+
+		last = span[1]
+	}
+
+	b.WriteString(body[last:])
+
+	return b.String()
+}
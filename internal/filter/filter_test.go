@@ -0,0 +1,120 @@
+package filter
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name   string
+		phrase string
+		want   string
+	}{
+		{
+			name:   "Single word",
+			phrase: "Kerfuffle",
+			want:   "kerfuffle",
+		},
+		{
+			name:   "Punctuation and spacing",
+			phrase: "  Bad--WORD!! ",
+			want:   "bad word",
+		},
+		{
+			name:   "No words",
+			phrase: "?!...",
+			want:   "",
+		},
+		{
+			name:   "Non-Latin",
+			phrase: "Café 日本語",
+			want:   "café 日本語",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Normalize(test.phrase)
+			if got != test.want {
+				t.Errorf("Normalize() = %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	f := New([]Rule{
+		{Phrase: "kerfuffle", Action: ActionMask},
+		{Phrase: "Sharbert", Action: ActionMask},
+		{Phrase: "bad word", Action: ActionMask},
+		{Phrase: "fornax", Action: ActionReject},
+		{Phrase: "crypto giveaway", Action: ActionFlag},
+		{Phrase: "", Action: ActionMask},
+		{Phrase: "ignored", Action: "delete"},
+	})
+
+	tests := []struct {
+		name string
+		body string
+		want Result
+	}{
+		{
+			name: "Clean",
+			body: "This is a clean chirp",
+			want: Result{Body: "This is a clean chirp"},
+		},
+		{
+			name: "Masks regardless of case and punctuation",
+			body: "What a Kerfuffle! (sharbert)",
+			want: Result{Body: "What a ****! (****)"},
+		},
+		{
+			name: "Doesn't mask inside longer words",
+			body: "kerfuffles and sharbertine",
+			want: Result{Body: "kerfuffles and sharbertine"},
+		},
+		{
+			name: "Masks phrases across punctuation",
+			body: "such a bad, word",
+			want: Result{Body: "such a ****"},
+		},
+		{
+			name: "Rejects",
+			body: "hello Fornax.",
+			want: Result{Body: "hello Fornax.", Rejected: true},
+		},
+		{
+			name: "Flags each phrase once",
+			body: "Crypto giveaway! crypto GIVEAWAY",
+			want: Result{Body: "Crypto giveaway! crypto GIVEAWAY", Flagged: []string{"crypto giveaway"}},
+		},
+		{
+			name: "Unknown actions are ignored",
+			body: "ignored",
+			want: Result{Body: "ignored"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := f.Apply(test.body)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Apply() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyOverlappingMasks(t *testing.T) {
+	f := New([]Rule{
+		{Phrase: "very bad", Action: ActionMask},
+		{Phrase: "bad word", Action: ActionMask},
+	})
+
+	got := f.Apply("a very bad word here").Body
+	want := "a **** here"
+	if got != want {
+		t.Errorf("Apply().Body = %q, want %q", got, want)
+	}
+}
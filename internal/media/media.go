@@ -0,0 +1,160 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+const (
+	// MaxPixels caps decoded image size so a small, highly compressed file
+	// can't make us allocate gigabytes. At up to 4 bytes a pixel, it keeps a
+	// decoded image under 64 MB, with room for a 12 megapixel photo.
+	MaxPixels = 16_000_000
+	// MaxConcurrentDecodes is how many images are decoded at once. Others
+	// wait their turn, so uploads can't add up to more memory than that.
+	MaxConcurrentDecodes = 2
+	ThumbnailSize        = 320
+)
+
+// decodeSlots holds a token for each image being decoded.
+var decodeSlots = make(chan struct{}, MaxConcurrentDecodes)
+
+var (
+	ErrUnsupportedType = errors.New("unsupported image type")
+	ErrTooManyPixels   = errors.New("image dimensions are too large")
+)
+
+// supportedTypes maps the content types we accept, as sniffed from the data
+// rather than trusted from the client, to their file extensions.
+var supportedTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Image describes an uploaded image. Data is the image to store in place of
+// the upload, without metadata such as EXIF that could give away where it
+// was taken. Thumbnail is a JPEG that fits within ThumbnailSize on both
+// sides.
+type Image struct {
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Data        []byte
+	Thumbnail   []byte
+}
+
+// Process sniffs the type of an uploaded image, checks it decodes, strips
+// its metadata and generates its thumbnail. JPEGs are turned upright, since
+// their EXIF orientation doesn't survive, and WebP images are stored as PNG
+// as there's no WebP encoder.
+func Process(data []byte) (Image, error) {
+	contentType := http.DetectContentType(data)
+	extension, ok := supportedTypes[contentType]
+	if !ok {
+		return Image{}, ErrUnsupportedType
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrUnsupportedType
+	}
+
+	if config.Width*config.Height > MaxPixels {
+		return Image{}, ErrTooManyPixels
+	}
+
+	decodeSlots <- struct{}{}
+	defer func() { <-decodeSlots }()
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, ErrUnsupportedType
+	}
+
+	var stored []byte
+	switch contentType {
+	case "image/gif":
+		stored, err = stripGIF(data)
+	case "image/jpeg":
+		img = orient(img, jpegOrientation(data))
+		stored, err = jpegBytes(img, 90)
+	default:
+		// PNG, or WebP, which has no encoder.
+		contentType, extension = "image/png", ".png"
+		stored, err = pngBytes(img)
+	}
+
+	if err != nil {
+		return Image{}, err
+	}
+
+	thumbnail, err := Thumbnail(img, ThumbnailSize)
+	if err != nil {
+		return Image{}, err
+	}
+
+	return Image{
+		ContentType: contentType,
+		Extension:   extension,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Data:        stored,
+		Thumbnail:   thumbnail,
+	}, nil
+}
+
+func jpegBytes(img image.Image, quality int) ([]byte, error) {
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality})
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func pngBytes(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Thumbnail scales img down to fit within size on both sides, keeping its
+// aspect ratio, and encodes it as a JPEG. Smaller images aren't scaled up.
+// Transparent areas are filled with white since JPEG has no alpha.
+func Thumbnail(img image.Image, size int) ([]byte, error) {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if width > size || height > size {
+		if width >= height {
+			height = max(1, height*size/width)
+			width = size
+		} else {
+			width = max(1, width*size/height)
+			height = size
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	return jpegBytes(dst, 80)
+}
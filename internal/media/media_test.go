@@ -0,0 +1,191 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		t.Fatalf("png.Encode() error = %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            []byte
+		wantErr         error
+		wantContentType string
+		wantThumbWidth  int
+		wantThumbHeight int
+	}{
+		{
+			name:            "Landscape PNG is scaled down",
+			data:            encodePNG(t, 1280, 640),
+			wantContentType: "image/png",
+			wantThumbWidth:  320,
+			wantThumbHeight: 160,
+		},
+		{
+			name:            "Portrait PNG is scaled down",
+			data:            encodePNG(t, 400, 800),
+			wantContentType: "image/png",
+			wantThumbWidth:  160,
+			wantThumbHeight: 320,
+		},
+		{
+			name:            "Small PNG isn't scaled up",
+			data:            encodePNG(t, 40, 30),
+			wantContentType: "image/png",
+			wantThumbWidth:  40,
+			wantThumbHeight: 30,
+		},
+		{
+			name:    "Plain text",
+			data:    []byte("definitely not an image"),
+			wantErr: ErrUnsupportedType,
+		},
+		{
+			name:    "Truncated PNG",
+			data:    encodePNG(t, 100, 100)[:40],
+			wantErr: ErrUnsupportedType,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := Process(test.data)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Process() error = %v, wantErr %v", err, test.wantErr)
+			}
+			if test.wantErr != nil {
+				return
+			}
+
+			if img.ContentType != test.wantContentType {
+				t.Errorf("Process() content type = %q, want %q", img.ContentType, test.wantContentType)
+			}
+
+			thumbnail, err := jpeg.DecodeConfig(bytes.NewReader(img.Thumbnail))
+			if err != nil {
+				t.Fatalf("thumbnail isn't a JPEG: %v", err)
+			}
+
+			if thumbnail.Width != test.wantThumbWidth || thumbnail.Height != test.wantThumbHeight {
+				t.Errorf("thumbnail is %dx%d, want %dx%d", thumbnail.Width, thumbnail.Height, test.wantThumbWidth, test.wantThumbHeight)
+			}
+		})
+	}
+}
+
+// withEXIF inserts an EXIF segment into a JPEG that sets its orientation and
+// carries a marker standing in for GPS data.
+func withEXIF(t *testing.T, data []byte, orientation uint16) []byte {
+	t.Helper()
+
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08")
+	tiff = append(tiff, 0x00, 0x01)
+	tiff = append(tiff, 0x01, 0x12, 0x00, 0x03, 0x00, 0x00, 0x00, 0x01, byte(orientation>>8), byte(orientation), 0x00, 0x00)
+	tiff = append(tiff, 0x00, 0x00, 0x00, 0x00)
+	tiff = append(tiff, "GPS-SECRET"...)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	length := len(segment) + 2
+
+	out := append([]byte{}, data[:2]...)
+	out = append(out, 0xFF, 0xE1, byte(length>>8), byte(length))
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestProcessStripsMetadata(t *testing.T) {
+	var jpegBuf bytes.Buffer
+	err := jpeg.Encode(&jpegBuf, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil)
+	if err != nil {
+		t.Fatalf("jpeg.Encode() error = %v", err)
+	}
+
+	var gifBuf bytes.Buffer
+	err = gif.Encode(&gifBuf, image.NewPaletted(image.Rect(0, 0, 10, 10), color.Palette{color.Black, color.White}), nil)
+	if err != nil {
+		t.Fatalf("gif.Encode() error = %v", err)
+	}
+
+	// A comment extension just before the trailer.
+	gifData := gifBuf.Bytes()
+	gifData = append(gifData[:len(gifData)-1:len(gifData)-1], 0x21, 0xFE, 10)
+	gifData = append(gifData, "GPS-SECRET"...)
+	gifData = append(gifData, 0x00, 0x3B)
+
+	tests := []struct {
+		name            string
+		data            []byte
+		wantContentType string
+		wantWidth       int
+		wantHeight      int
+	}{
+		{
+			name:            "JPEG is turned upright",
+			data:            withEXIF(t, jpegBuf.Bytes(), 6),
+			wantContentType: "image/jpeg",
+			wantWidth:       20,
+			wantHeight:      40,
+		},
+		{
+			name:            "JPEG without rotation",
+			data:            withEXIF(t, jpegBuf.Bytes(), 1),
+			wantContentType: "image/jpeg",
+			wantWidth:       40,
+			wantHeight:      20,
+		},
+		{
+			name:            "GIF comments are dropped",
+			data:            gifData,
+			wantContentType: "image/gif",
+			wantWidth:       10,
+			wantHeight:      10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			img, err := Process(test.data)
+			if err != nil {
+				t.Fatalf("Process() error = %v", err)
+			}
+
+			if img.ContentType != test.wantContentType {
+				t.Errorf("Process() content type = %q, want %q", img.ContentType, test.wantContentType)
+			}
+
+			if bytes.Contains(img.Data, []byte("GPS-SECRET")) {
+				t.Errorf("Process() kept the metadata")
+			}
+
+			config, _, err := image.DecodeConfig(bytes.NewReader(img.Data))
+			if err != nil {
+				t.Fatalf("stored image doesn't decode: %v", err)
+			}
+
+			if config.Width != test.wantWidth || config.Height != test.wantHeight {
+				t.Errorf("stored image is %dx%d, want %dx%d", config.Width, config.Height, test.wantWidth, test.wantHeight)
+			}
+		})
+	}
+}
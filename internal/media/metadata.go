@@ -0,0 +1,230 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// jpegOrientation returns the EXIF orientation of a JPEG, from 1 to 8, or 1
+// if it has none. Re-encoding drops the EXIF data, so the orientation has to
+// be applied to the pixels instead.
+func jpegOrientation(data []byte) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+
+	i := 2
+	for i+4 <= len(data) {
+		if data[i] != 0xFF {
+			return 1
+		}
+
+		marker := data[i+1]
+		switch {
+		case marker == 0xFF:
+			// Fill byte before the marker.
+			i++
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// EXIF comes before the image data.
+			return 1
+		case marker >= 0xD0 && marker <= 0xD7:
+			i += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		if length < 2 || i+2+length > len(data) {
+			return 1
+		}
+
+		segment := data[i+4 : i+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return tiffOrientation(segment[6:])
+		}
+
+		i += 2 + length
+	}
+
+	return 1
+}
+
+// tiffOrientation reads the orientation tag from the first IFD of the TIFF
+// structure inside an EXIF segment.
+func tiffOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(tiff[4:]))
+	if offset < 8 || offset+2 > len(tiff) {
+		return 1
+	}
+
+	count := int(order.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+
+		if order.Uint16(tiff[entry:]) != 0x0112 {
+			continue
+		}
+
+		orientation := int(order.Uint16(tiff[entry+8:]))
+		if orientation < 1 || orientation > 8 {
+			return 1
+		}
+		return orientation
+	}
+
+	return 1
+}
+
+// orient turns img the way an EXIF orientation says it should be shown.
+func orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // Mirrored.
+				dx, dy = width-1-x, y
+			case 3: // Upside down.
+				dx, dy = width-1-x, height-1-y
+			case 4: // Upside down and mirrored.
+				dx, dy = x, height-1-y
+			case 5: // Transposed.
+				dx, dy = y, x
+			case 6: // Turned a quarter clockwise.
+				dx, dy = height-1-y, x
+			case 7: // Transversed.
+				dx, dy = height-1-y, width-1-x
+			case 8: // Turned a quarter anticlockwise.
+				dx, dy = y, width-1-x
+			}
+
+			dst.Set(dx, dy, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+
+	return dst
+}
+
+// stripGIF copies a GIF block by block, leaving out comments and application
+// extensions other than the one that makes animations loop. GIFs are copied
+// rather than re-encoded so animations survive without decoding every frame.
+func stripGIF(data []byte) ([]byte, error) {
+	if len(data) < 13 {
+		return nil, ErrUnsupportedType
+	}
+
+	// The header, logical screen descriptor and global color table.
+	start := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		start += 3 << ((flags & 0x07) + 1)
+	}
+	if start > len(data) {
+		return nil, ErrUnsupportedType
+	}
+
+	out := make([]byte, 0, len(data))
+	out = append(out, data[:start]...)
+
+	i := start
+	for i < len(data) {
+		switch data[i] {
+		case 0x3B: // Trailer.
+			return append(out, 0x3B), nil
+		case 0x2C: // Image descriptor, local color table and image data.
+			if i+10 > len(data) {
+				return nil, ErrUnsupportedType
+			}
+
+			dataStart := i + 10
+			if flags := data[i+9]; flags&0x80 != 0 {
+				dataStart += 3 << ((flags & 0x07) + 1)
+			}
+
+			// Skip the LZW minimum code size.
+			end, err := skipSubBlocks(data, dataStart+1)
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, data[i:end]...)
+			i = end
+		case 0x21: // Extension.
+			if i+2 > len(data) {
+				return nil, ErrUnsupportedType
+			}
+
+			end, err := skipSubBlocks(data, i+2)
+			if err != nil {
+				return nil, err
+			}
+
+			label := data[i+1]
+			keep := label == 0xF9 || label == 0x01 || (label == 0xFF && isLoopExtension(data[i+2:end]))
+			if keep {
+				out = append(out, data[i:end]...)
+			}
+			i = end
+		default:
+			return nil, ErrUnsupportedType
+		}
+	}
+
+	return nil, ErrUnsupportedType
+}
+
+// skipSubBlocks returns the index just past the data sub-blocks starting at
+// i, which end with an empty one.
+func skipSubBlocks(data []byte, i int) (int, error) {
+	for i < len(data) {
+		size := int(data[i])
+		i++
+		if size == 0 {
+			return i, nil
+		}
+		i += size
+	}
+
+	return 0, ErrUnsupportedType
+}
+
+// isLoopExtension reports whether the sub-blocks of an application
+// extension are the NETSCAPE2.0 or ANIMEXTS1.0 one that sets how many times
+// an animation plays.
+func isLoopExtension(blocks []byte) bool {
+	if len(blocks) < 12 || blocks[0] != 11 {
+		return false
+	}
+
+	id := string(blocks[1:12])
+	return id == "NETSCAPE2.0" || id == "ANIMEXTS1.0"
+}
@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalPut(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		wantErr error
+	}{
+		{
+			name:    "Valid key",
+			key:     "image.png",
+			wantErr: nil,
+		},
+		{
+			name:    "Empty key",
+			key:     "",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "Path traversal",
+			key:     "../image.png",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "Nested path",
+			key:     "a/image.png",
+			wantErr: ErrInvalidKey,
+		},
+		{
+			name:    "Hidden file",
+			key:     ".image.png",
+			wantErr: ErrInvalidKey,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			store, err := NewLocal(dir, "/app/media/")
+			if err != nil {
+				t.Fatalf("NewLocal() error = %v", err)
+			}
+
+			err = store.Put(context.Background(), test.key, strings.NewReader("data"))
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Put() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			entries, _ := os.ReadDir(dir)
+			if test.wantErr != nil {
+				if len(entries) != 0 {
+					t.Errorf("Put() left %d files behind", len(entries))
+				}
+				return
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, test.key))
+			if err != nil || string(data) != "data" {
+				t.Errorf("Put() wrote %q, err %v", data, err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("Put() left %d files, want 1", len(entries))
+			}
+		})
+	}
+}
+
+func TestLocalDelete(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "/app/media")
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+
+	ctx := context.Background()
+	err = store.Put(ctx, "image.png", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		err = store.Delete(ctx, "image.png")
+		if err != nil {
+			t.Errorf("Delete() attempt %d error = %v", i+1, err)
+		}
+	}
+}
+
+func TestLocalURL(t *testing.T) {
+	store, err := NewLocal(t.TempDir(), "/app/media/")
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+
+	got := store.URL("a b.png")
+	want := "/app/media/a%20b.png"
+	if got != want {
+		t.Errorf("URL() = %q, want %q", got, want)
+	}
+}
@@ -1,23 +1,39 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
 	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+	"github.com/nurmuh-alhakim18/chirpy/internal/storage"
 )
 
 type apiConfig struct {
 	fileserverHits atomic.Int32
 	db             *database.Queries
+	dbConn         *sql.DB
 	platform       string
 	secretKeyJWT   string
 	polkaKey       string
+	// Longest chirps allowed, as counted by chirptext.Length.
+	maxChirpLength    int
+	maxChirpLengthRed int
+	notifier          *notifier
+	broker            *broker
+	storage           storage.Store
 }
 
 func main() {
@@ -38,6 +54,16 @@ func main() {
 
 	dbQueries := database.New(db)
 
+	// Commands such as `chirpy bootstrap-admin <email>` only need the
+	// database, so they run before the server's own settings are checked.
+	if len(os.Args) > 1 {
+		err := runCommand(context.Background(), dbQueries, os.Args[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	platform := os.Getenv("PLATFORM")
 	if platform == "" {
 		log.Fatal("PLATFORM must be set")
@@ -53,38 +79,203 @@ func main() {
 		log.Fatal("POLKA_KEY must be set")
 	}
 
+	maxChirpLength := envInt("MAX_CHIRP_LENGTH", 140)
+	maxChirpLengthRed := envInt("MAX_CHIRP_LENGTH_RED", 280)
+	if maxChirpLengthRed < maxChirpLength {
+		log.Fatal("MAX_CHIRP_LENGTH_RED can't be less than MAX_CHIRP_LENGTH")
+	}
+
+	// Set PUBSUB=postgres to share realtime events between instances through
+	// Postgres LISTEN/NOTIFY. By default they stay within this process.
+	usePostgresPubSub := os.Getenv("PUBSUB") == "postgres"
+
+	// Uploads live under the fileserver root so /app serves them.
+	mediaStore, err := storage.NewLocal(filepath.Join(filepathRoot, "media"), "/app/media")
+	if err != nil {
+		log.Fatalf("Error creating media storage: %v", err)
+	}
+
+	broker := newBroker(dbQueries, usePostgresPubSub)
+
 	apiCfg := apiConfig{
-		fileserverHits: atomic.Int32{},
-		db:             dbQueries,
-		platform:       platform,
-		secretKeyJWT:   secretKeyJWT,
-		polkaKey:       polkaKey,
+		fileserverHits:    atomic.Int32{},
+		db:                dbQueries,
+		dbConn:            db,
+		platform:          platform,
+		secretKeyJWT:      secretKeyJWT,
+		polkaKey:          polkaKey,
+		maxChirpLength:    maxChirpLength,
+		maxChirpLengthRed: maxChirpLengthRed,
+		notifier:          newNotifier(dbQueries, broker),
+		broker:            broker,
+		storage:           mediaStore,
 	}
 
+	go apiCfg.notifier.run()
+
 	mux := http.NewServeMux()
 	mux.Handle("/app/", http.StripPrefix("/app", apiCfg.metricsIncMiddleware(http.FileServer(http.Dir(filepathRoot)))))
 	mux.HandleFunc("GET /api/healthz", handlerReadiness)
-	mux.HandleFunc("GET /admin/metrics", apiCfg.handlerMetrics)
-	mux.HandleFunc("POST /admin/reset", apiCfg.handlerReset)
+
+	signedIn := func(h http.HandlerFunc) http.Handler {
+		return apiCfg.requireAuth(h)
+	}
+	optional := func(h http.HandlerFunc) http.Handler {
+		return apiCfg.optionalAuth(h)
+	}
+	requireAdmin := func(h http.HandlerFunc) http.Handler {
+		return apiCfg.requireRole(auth.RoleAdmin, h)
+	}
+
+	// Everything under /admin needs at least a moderator. Routes that change
+	// how the whole site runs are wrapped again for admins only.
+	adminMux := http.NewServeMux()
+	adminMux.Handle("GET /admin/metrics", requireAdmin(apiCfg.handlerMetrics))
+	adminMux.Handle("POST /admin/reset", requireAdmin(apiCfg.handlerReset))
+	adminMux.Handle("GET /admin/filter/rules", requireAdmin(apiCfg.handlerGetFilterRules))
+	adminMux.Handle("POST /admin/filter/rules", requireAdmin(apiCfg.handlerCreateFilterRule))
+	adminMux.Handle("PUT /admin/filter/rules/{ruleId}", requireAdmin(apiCfg.handlerUpdateFilterRule))
+	adminMux.Handle("DELETE /admin/filter/rules/{ruleId}", requireAdmin(apiCfg.handlerDeleteFilterRule))
+	adminMux.Handle("PUT /admin/users/{userId}/role", requireAdmin(apiCfg.handlerSetUserRole))
+	adminMux.HandleFunc("GET /admin/flagged-chirps", apiCfg.handlerGetFlaggedChirps)
+	adminMux.HandleFunc("DELETE /admin/flagged-chirps/{chirpId}", apiCfg.handlerDismissChirpFlag)
+	adminMux.HandleFunc("GET /admin/reports", apiCfg.handlerGetReports)
+	adminMux.HandleFunc("POST /admin/reports/{reportId}/resolve", apiCfg.handlerResolveReport)
+	adminMux.HandleFunc("GET /admin/chirps/{chirpId}", apiCfg.handlerGetChirpForReview)
+	adminMux.HandleFunc("POST /admin/chirps/{chirpId}/hide", apiCfg.handlerHideChirp)
+	adminMux.HandleFunc("POST /admin/chirps/{chirpId}/unhide", apiCfg.handlerUnhideChirp)
+	adminMux.HandleFunc("POST /admin/users/{userId}/suspend", apiCfg.handlerSuspendUser)
+	adminMux.HandleFunc("POST /admin/users/{userId}/unsuspend", apiCfg.handlerUnsuspendUser)
+	adminMux.HandleFunc("GET /admin/moderation-log", apiCfg.handlerGetModerationLog)
+	mux.Handle("/admin/", apiCfg.requireRole(auth.RoleModerator, adminMux))
 
 	mux.HandleFunc("POST /api/users", apiCfg.handlerCreateUser)
-	mux.HandleFunc("PUT /api/users", apiCfg.handlerUpdateUser)
+	mux.Handle("PUT /api/users", signedIn(apiCfg.handlerUpdateUser))
+	mux.Handle("PATCH /api/users/me", signedIn(apiCfg.handlerUpdateProfile))
+	mux.HandleFunc("GET /api/users/{username}", apiCfg.handlerGetUserProfile)
 	mux.HandleFunc("POST /api/login", apiCfg.handlerUserLogin)
 	mux.HandleFunc("POST /api/refresh", apiCfg.handlerRefreshToken)
 	mux.HandleFunc("POST /api/revoke", apiCfg.handlerRevokeToken)
 
+	mux.Handle("POST /api/users/{userId}/follow", signedIn(apiCfg.handlerFollowUser))
+	mux.Handle("DELETE /api/users/{userId}/follow", signedIn(apiCfg.handlerUnfollowUser))
+	mux.HandleFunc("GET /api/users/{userId}/followers", apiCfg.handlerGetFollowers)
+	mux.HandleFunc("GET /api/users/{userId}/following", apiCfg.handlerGetFollowing)
+	mux.Handle("GET /api/timeline", signedIn(apiCfg.handlerGetTimeline))
+
+	mux.Handle("POST /api/users/{userId}/block", signedIn(apiCfg.handlerBlockUser))
+	mux.Handle("DELETE /api/users/{userId}/block", signedIn(apiCfg.handlerUnblockUser))
+	mux.Handle("POST /api/users/{userId}/mute", signedIn(apiCfg.handlerMuteUser))
+	mux.Handle("DELETE /api/users/{userId}/mute", signedIn(apiCfg.handlerUnmuteUser))
+	mux.Handle("POST /api/users/{userId}/report", signedIn(apiCfg.handlerReportUser))
+	mux.Handle("GET /api/blocks", signedIn(apiCfg.handlerGetBlockedUsers))
+	mux.Handle("GET /api/mutes", signedIn(apiCfg.handlerGetMutedUsers))
+	mux.Handle("GET /api/muted-keywords", signedIn(apiCfg.handlerGetMutedKeywords))
+	mux.Handle("PUT /api/muted-keywords/{keyword}", signedIn(apiCfg.handlerMuteKeyword))
+	mux.Handle("DELETE /api/muted-keywords/{keyword}", signedIn(apiCfg.handlerUnmuteKeyword))
+
+	mux.Handle("GET /api/notifications", signedIn(apiCfg.handlerGetNotifications))
+	mux.Handle("POST /api/notifications/read", signedIn(apiCfg.handlerMarkNotificationsRead))
+
 	mux.HandleFunc("POST /api/polka/webhooks", apiCfg.handlerUpgradeChirpyRed)
 
-	mux.HandleFunc("POST /api/chirps", apiCfg.handlerCreateChirp)
-	mux.HandleFunc("GET /api/chirps", apiCfg.handlerGetAllChirps)
-	mux.HandleFunc("GET /api/chirps/{chirpId}", apiCfg.handlerGetChirpById)
-	mux.HandleFunc("DELETE /api/chirps/{chirpId}", apiCfg.handlerDeleteChirp)
+	mux.Handle("POST /api/media", signedIn(apiCfg.handlerUploadMedia))
+
+	mux.Handle("POST /api/chirps", signedIn(apiCfg.handlerCreateChirp))
+	mux.Handle("GET /api/chirps", optional(apiCfg.handlerGetAllChirps))
+	mux.Handle("GET /api/chirps/{chirpId}", optional(apiCfg.handlerGetChirpById))
+	mux.Handle("PATCH /api/chirps/{chirpId}", signedIn(apiCfg.handlerUpdateChirp))
+	mux.Handle("DELETE /api/chirps/{chirpId}", signedIn(apiCfg.handlerDeleteChirp))
+	mux.Handle("POST /api/chirps/{chirpId}/restore", signedIn(apiCfg.handlerRestoreChirp))
+	mux.Handle("POST /api/chirps/{chirpId}/report", signedIn(apiCfg.handlerReportChirp))
+	mux.Handle("GET /api/chirps/{chirpId}/history", optional(apiCfg.handlerGetChirpHistory))
+	mux.Handle("GET /api/chirps/{chirpId}/thread", optional(apiCfg.handlerGetChirpThread))
+	mux.Handle("POST /api/chirps/{chirpId}/poll/vote", signedIn(apiCfg.handlerVotePoll))
+	mux.Handle("PUT /api/chirps/{chirpId}/like", signedIn(apiCfg.handlerLikeChirp))
+	mux.Handle("DELETE /api/chirps/{chirpId}/like", signedIn(apiCfg.handlerUnlikeChirp))
+	mux.Handle("PUT /api/chirps/{chirpId}/bookmark", signedIn(apiCfg.handlerBookmarkChirp))
+	mux.Handle("DELETE /api/chirps/{chirpId}/bookmark", signedIn(apiCfg.handlerRemoveBookmark))
+	mux.Handle("PUT /api/chirps/{chirpId}/pin", signedIn(apiCfg.handlerPinChirp))
+	mux.Handle("DELETE /api/chirps/{chirpId}/pin", signedIn(apiCfg.handlerUnpinChirp))
+	mux.Handle("GET /api/bookmarks", signedIn(apiCfg.handlerGetBookmarks))
+
+	mux.Handle("POST /api/drafts", signedIn(apiCfg.handlerCreateDraft))
+	mux.Handle("GET /api/drafts", signedIn(apiCfg.handlerGetDrafts))
+	mux.Handle("GET /api/drafts/{draftId}", signedIn(apiCfg.handlerGetDraftById))
+	mux.Handle("PUT /api/drafts/{draftId}", signedIn(apiCfg.handlerUpdateDraft))
+	mux.Handle("DELETE /api/drafts/{draftId}", signedIn(apiCfg.handlerDeleteDraft))
+	mux.Handle("POST /api/drafts/{draftId}/publish", signedIn(apiCfg.handlerPublishDraft))
+
+	mux.Handle("GET /api/stream/chirps", tokenFromQuery(optional(apiCfg.handlerStreamChirps)))
+	mux.Handle("GET /api/ws", tokenFromQuery(signedIn(apiCfg.handlerWebSocket)))
+
+	mux.Handle("GET /api/search/chirps", optional(apiCfg.handlerSearchChirps))
+	mux.HandleFunc("GET /api/tags/trending", apiCfg.handlerGetTrendingTags)
+	mux.Handle("GET /api/tags/{tag}/chirps", optional(apiCfg.handlerGetChirpsByTag))
 
 	server := http.Server{
 		Handler: mux,
 		Addr:    ":" + port,
 	}
+	server.RegisterOnShutdown(apiCfg.broker.close)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if usePostgresPubSub {
+		go func() {
+			err := apiCfg.broker.listen(ctx, dbURL, apiCfg.loadEvent)
+			if err != nil {
+				log.Fatalf("Error listening for events: %v", err)
+			}
+		}()
+	}
+
+	go apiCfg.runPurger(ctx)
+
+	// The scheduler notifies users about the chirps it publishes, so it has
+	// to stop before the notifier does.
+	schedulerDone := make(chan struct{})
+	go func() {
+		defer close(schedulerDone)
+		apiCfg.runScheduler(ctx)
+	}()
+
+	go func() {
+		log.Printf("Serving on port: %s", port)
+		err := server.ListenAndServe()
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal(err)
+		}
+	}()
+
+	<-ctx.Done()
+	log.Println("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = server.Shutdown(shutdownCtx)
+	if err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+
+	<-schedulerDone
+	apiCfg.notifier.close()
+}
+
+// envInt reads a positive integer setting from the environment, using
+// fallback when it isn't set.
+func envInt(name string, fallback int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		log.Fatalf("%s must be a positive integer", name)
+	}
 
-	log.Printf("Serving on port: %s", port)
-	log.Fatal(server.ListenAndServe())
+	return n
 }
@@ -2,6 +2,8 @@ package main
 
 import (
 	"net/http"
+
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
 )
 
 func (cfg *apiConfig) metricsIncMiddleware(next http.Handler) http.Handler {
@@ -11,27 +13,114 @@ func (cfg *apiConfig) metricsIncMiddleware(next http.Handler) http.Handler {
 	})
 }
 
-// type contextKey string
+// authRealm names the protection space in WWW-Authenticate challenges.
+const authRealm = "chirpy"
+
+// respAuthError responds with an authentication or authorization failure,
+// challenging the client to present a bearer token. errCode is one of the
+// RFC 6750 error codes, or empty when the request had no token at all.
+func respAuthError(w http.ResponseWriter, statusCode int, errCode, msg string, err error) {
+	w.Header().Set("WWW-Authenticate", auth.Challenge(authRealm, errCode, msg))
+	respError(w, statusCode, msg, err)
+}
+
+// authenticate adds the principal named by r's access token to its context.
+// Without a token, r is passed on unchanged if optional is set. Otherwise
+// it responds with an error and returns false.
+func (cfg *apiConfig) authenticate(w http.ResponseWriter, r *http.Request, optional bool) (*http.Request, bool) {
+	// Already done further out, as when admin routes are nested.
+	if _, ok := auth.FromContext(r.Context()); ok {
+		return r, true
+	}
 
-// const userIdKey contextKey = "userId"
+	if r.Header.Get("Authorization") == "" {
+		if optional {
+			return r, true
+		}
 
-// func (cfg *apiConfig) authMiddleware(next http.Handler) http.Handler {
-// 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-// 		token, err := auth.GetBearerToken(r.Header)
-// 		if err != nil {
-// 			respError(w, 401, "Couldn't find JWT", err)
-// 			return
-// 		}
+		respAuthError(w, 401, "", "Couldn't find JWT", nil)
+		return nil, false
+	}
 
-// 		userId, err := auth.ValidateJWT(token, cfg.secretKeyJWT)
-// 		if err != nil {
-// 			respError(w, 401, "Couldn't validate JWT", err)
-// 			return
-// 		}
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respAuthError(w, 401, auth.ErrInvalidToken, "Couldn't find JWT", err)
+		return nil, false
+	}
 
-// 		ctx := context.WithValue(r.Context(), userIdKey, userId)
-// 		r = r.WithContext(ctx)
+	principal, err := auth.ParseJWT(token, cfg.secretKeyJWT)
+	if err != nil {
+		respAuthError(w, 401, auth.ErrInvalidToken, "Couldn't validate JWT", err)
+		return nil, false
+	}
+
+	return r.WithContext(auth.NewContext(r.Context(), principal)), true
+}
+
+// requireAuth lets requests through to next only with a valid access token.
+// Handlers read who made the request with auth.FromContext.
+func (cfg *apiConfig) requireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, ok := cfg.authenticate(w, r, false)
+		if !ok {
+			return
+		}
 
-// 		next.ServeHTTP(w, r)
-// 	})
-// }
+		next.ServeHTTP(w, r)
+	})
+}
+
+// optionalAuth is requireAuth for routes where signing in is optional.
+// Anonymous requests have no principal, so auth.UserIdFromContext gives
+// uuid.Nil; a token that is present but invalid is still rejected.
+func (cfg *apiConfig) optionalAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, ok := cfg.authenticate(w, r, true)
+		if !ok {
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireRole lets requests through to next only from signed-in users with
+// at least role. The role is checked against the database as well as the
+// token, so demoting or suspending someone takes effect straight away.
+func (cfg *apiConfig) requireRole(role auth.Role, next http.Handler) http.Handler {
+	return cfg.requireAuth(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, _ := auth.FromContext(r.Context())
+		if !principal.HasRole(role) {
+			respAuthError(w, 403, auth.ErrInsufficientScope, "Not allowed", nil)
+			return
+		}
+
+		userDB, err := cfg.db.GetUserById(r.Context(), principal.UserId)
+		if err != nil {
+			respAuthError(w, 401, auth.ErrInvalidToken, "Couldn't find user", err)
+			return
+		}
+
+		if !auth.Role(userDB.Role).Includes(role) || userDB.SuspendedAt.Valid {
+			respAuthError(w, 403, auth.ErrInsufficientScope, "Not allowed", nil)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
+
+// tokenFromQuery passes the token query parameter on as a bearer token, for
+// clients that can't set headers, like browsers opening a WebSocket or an
+// EventSource.
+func tokenFromQuery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token != "" && r.Header.Get("Authorization") == "" {
+			r = r.Clone(r.Context())
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const (
+	notificationLike    = "like"
+	notificationReply   = "reply"
+	notificationFollow  = "follow"
+	notificationMention = "mention"
+	notificationRechirp = "rechirp"
+	notificationQuote   = "quote"
+)
+
+const notificationQueueSize = 1024
+
+// notifier writes notifications from a background goroutine so handlers
+// never wait on them, then pushes them to realtime subscribers. If the queue
+// is full the notification is dropped and logged rather than slowing the
+// request down.
+type notifier struct {
+	db     *database.Queries
+	broker *broker
+	queue  chan database.CreateNotificationsParams
+	done   chan struct{}
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func newNotifier(db *database.Queries, broker *broker) *notifier {
+	return &notifier{
+		db:     db,
+		broker: broker,
+		queue:  make(chan database.CreateNotificationsParams, notificationQueueSize),
+		done:   make(chan struct{}),
+	}
+}
+
+// run writes queued notifications until close is called.
+func (n *notifier) run() {
+	defer close(n.done)
+
+	for params := range n.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		notificationsDB, err := n.db.CreateNotifications(ctx, params)
+		if err != nil {
+			log.Printf("Error creating %s notifications: %v", params.Kind, err)
+		}
+
+		for _, notificationDB := range notificationsDB {
+			n.broker.publish(event{
+				Kind:           eventNotificationCreated,
+				UserId:         notificationDB.UserID,
+				ChirpId:        notificationDB.ChirpID.UUID,
+				NotificationId: notificationDB.ID,
+			}, notificationFromDB(notificationDB, ""))
+		}
+
+		cancel()
+	}
+}
+
+// close stops accepting notifications and waits for the queue to drain.
+// Handlers still running when the server gives up waiting on them may call
+// notify afterwards, and their notifications are dropped.
+func (n *notifier) close() {
+	n.mu.Lock()
+	n.closed = true
+	close(n.queue)
+	n.mu.Unlock()
+
+	<-n.done
+}
+
+// notify queues a notification of the given kind for each recipient other
+// than the actor. chirpId may be uuid.Nil for events that aren't about a
+// chirp.
+func (n *notifier) notify(recipients []uuid.UUID, actorId uuid.UUID, kind string, chirpId uuid.UUID) {
+	userIds := make([]uuid.UUID, 0, len(recipients))
+	for _, recipient := range recipients {
+		if recipient != actorId {
+			userIds = append(userIds, recipient)
+		}
+	}
+
+	if len(userIds) == 0 {
+		return
+	}
+
+	params := database.CreateNotificationsParams{
+		UserIds: userIds,
+		ActorID: actorId,
+		Kind:    kind,
+		ChirpID: uuid.NullUUID{
+			UUID:  chirpId,
+			Valid: chirpId != uuid.Nil,
+		},
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.closed {
+		log.Printf("Notifier is closed, dropping %s notification", kind)
+		return
+	}
+
+	select {
+	case n.queue <- params:
+	default:
+		log.Printf("Notification queue is full, dropping %s notification", kind)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// page holds the limit and cursor parsed from a list request. The cursor
+// fields are only valid when the client sent one.
+type page struct {
+	limit           int
+	cursorCreatedAt sql.NullTime
+	cursorId        uuid.NullUUID
+}
+
+func parsePage(r *http.Request) (page, error) {
+	limit, err := parseLimit(r.URL.Query().Get("limit"))
+	if err != nil {
+		return page{}, err
+	}
+
+	p := page{limit: limit}
+
+	cursorString := r.URL.Query().Get("cursor")
+	if cursorString != "" {
+		p.cursorCreatedAt.Time, p.cursorId.UUID, err = decodeCursor(cursorString)
+		if err != nil {
+			return page{}, err
+		}
+		p.cursorCreatedAt.Valid = true
+		p.cursorId.Valid = true
+	}
+
+	return p, nil
+}
+
+// fetchLimit is the number of rows to ask the database for: one more than the
+// page size, so we know whether there's another page.
+func (p page) fetchLimit() int32 {
+	return int32(p.limit + 1)
+}
+
+// parseLimit reads the limit query parameter, falling back to the default
+// page size when it's empty.
+func parseLimit(limitString string) (int, error) {
+	if limitString == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(limitString)
+	if err != nil || limit < 1 {
+		return 0, errors.New("limit must be a positive integer")
+	}
+
+	if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+
+	return limit, nil
+}
+
+// encodeCursor builds an opaque cursor pointing at the given row. Rows are
+// ordered by (created_at, id), so both are needed to resume after ties.
+func encodeCursor(createdAt time.Time, id uuid.UUID) string {
+	raw := createdAt.UTC().Format(time.RFC3339Nano) + "," + id.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeCursor(cursor string) (time.Time, uuid.UUID, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	createdAtString, idString, ok := strings.Cut(string(raw), ",")
+	if !ok {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, createdAtString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	id, err := uuid.Parse(idString)
+	if err != nil {
+		return time.Time{}, uuid.Nil, errors.New("invalid cursor")
+	}
+
+	return createdAt, id, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const (
+	minPollOptions      = 2
+	maxPollOptions      = 4
+	maxPollOptionLength = 25
+	minPollDuration     = 5 * time.Minute
+	maxPollDuration     = 7 * 24 * time.Hour
+)
+
+// poll is attached to a chirp. Vote counts are left out until the viewer has
+// voted or the poll has closed, so early results don't sway anyone.
+type poll struct {
+	Options     []pollOption `json:"options"`
+	ExpiresAt   time.Time    `json:"expires_at"`
+	Closed      bool         `json:"closed"`
+	VotedOption *int         `json:"voted_option"`
+	TotalVotes  *int         `json:"total_votes,omitempty"`
+}
+
+type pollOption struct {
+	Position int    `json:"position"`
+	Text     string `json:"text"`
+	Votes    *int   `json:"votes,omitempty"`
+}
+
+// pollParams is the poll part of a create chirp request.
+type pollParams struct {
+	Options   []string  `json:"options"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// validatePoll checks a new poll and returns the params to store it, minus
+// the chirp ID.
+func validatePoll(params pollParams) (database.CreatePollParams, error) {
+	if len(params.Options) < minPollOptions || len(params.Options) > maxPollOptions {
+		return database.CreatePollParams{}, fmt.Errorf("a poll must have %d to %d options", minPollOptions, maxPollOptions)
+	}
+
+	untilExpiry := time.Until(params.ExpiresAt)
+	if untilExpiry < minPollDuration || untilExpiry > maxPollDuration {
+		return database.CreatePollParams{}, errors.New("a poll must expire between 5 minutes and 7 days from now")
+	}
+
+	createParams := database.CreatePollParams{
+		ExpiresAt: params.ExpiresAt.UTC(),
+	}
+
+	seen := make(map[string]struct{}, len(params.Options))
+	for i, option := range params.Options {
+		option = strings.TrimSpace(option)
+		if option == "" || utf8.RuneCountInString(option) > maxPollOptionLength {
+			return database.CreatePollParams{}, fmt.Errorf("poll options must be 1 to %d characters", maxPollOptionLength)
+		}
+
+		key := strings.ToLower(option)
+		if _, ok := seen[key]; ok {
+			return database.CreatePollParams{}, errors.New("poll options must be different")
+		}
+		seen[key] = struct{}{}
+
+		createParams.Positions = append(createParams.Positions, int32(i))
+		createParams.Options = append(createParams.Options, option)
+	}
+
+	return createParams, nil
+}
+
+// buildPolls loads the polls on the given chirps as seen by viewerId, keyed
+// by chirp ID. Chirps without a poll are left out.
+func (cfg *apiConfig) buildPolls(ctx context.Context, viewerId uuid.UUID, chirpIds []uuid.UUID) (map[uuid.UUID]*poll, error) {
+	pollsDB, err := cfg.db.GetPolls(ctx, database.GetPollsParams{
+		ViewerID: viewerId,
+		ChirpIds: chirpIds,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	polls := make(map[uuid.UUID]*poll, len(pollsDB))
+	if len(pollsDB) == 0 {
+		return polls, nil
+	}
+
+	pollIds := make([]uuid.UUID, 0, len(pollsDB))
+	for _, pollDB := range pollsDB {
+		p := &poll{
+			Options:   []pollOption{},
+			ExpiresAt: pollDB.ExpiresAt,
+			Closed:    pollDB.Closed,
+		}
+
+		if pollDB.VotedPosition.Valid {
+			votedOption := int(pollDB.VotedPosition.Int32)
+			p.VotedOption = &votedOption
+		}
+
+		polls[pollDB.ChirpID] = p
+		pollIds = append(pollIds, pollDB.ChirpID)
+	}
+
+	optionsDB, err := cfg.db.GetPollOptions(ctx, pollIds)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[uuid.UUID]int, len(polls))
+	for _, optionDB := range optionsDB {
+		p := polls[optionDB.ChirpID]
+		option := pollOption{
+			Position: int(optionDB.Position),
+			Text:     optionDB.Text,
+		}
+
+		if p.Closed || p.VotedOption != nil {
+			votes := int(optionDB.VoteCount)
+			option.Votes = &votes
+			totals[optionDB.ChirpID] += votes
+		}
+
+		p.Options = append(p.Options, option)
+	}
+
+	for chirpId, p := range polls {
+		if p.Closed || p.VotedOption != nil {
+			total := totals[chirpId]
+			p.TotalVotes = &total
+		}
+	}
+
+	return polls, nil
+}
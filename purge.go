@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const (
+	chirpRestoreWindow = 30 * 24 * time.Hour
+	purgeInterval      = time.Hour
+	purgeBatchSize     = 100
+)
+
+// runPurger permanently removes chirps that were deleted more than
+// chirpRestoreWindow ago, checking every purgeInterval until ctx is
+// cancelled.
+func (cfg *apiConfig) runPurger(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		cfg.purgeDeletedChirps(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeDeletedChirps purges expired chirps in batches until none are left. A
+// chirp that still has replies becomes a tombstone instead, so the rest of
+// the thread keeps its place. Purging is idempotent, so it's fine for several
+// instances to do it at once.
+func (cfg *apiConfig) purgeDeletedChirps(ctx context.Context) {
+	for ctx.Err() == nil {
+		expiredDB, err := cfg.db.GetExpiredDeletedChirps(ctx, database.GetExpiredDeletedChirpsParams{
+			WindowSeconds: chirpRestoreWindow.Seconds(),
+			Limit:         purgeBatchSize,
+		})
+
+		if err != nil {
+			log.Printf("Error getting chirps to purge: %v", err)
+			return
+		}
+
+		for _, expired := range expiredDB {
+			err = cfg.purgeChirp(ctx, expired)
+			if err != nil {
+				log.Printf("Error purging chirp %s: %v", expired.ID, err)
+				return
+			}
+		}
+
+		if len(expiredDB) < purgeBatchSize {
+			return
+		}
+	}
+}
+
+// purgeChirp removes or tombstones one expired chirp in a transaction, so a
+// failure leaves it to be tried again on the next run. Its files are only
+// deleted once the rows pointing at them are gone.
+func (cfg *apiConfig) purgeChirp(ctx context.Context, expired database.GetExpiredDeletedChirpsRow) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := cfg.db.WithTx(tx)
+
+	var deletedDB []database.DeleteAttachmentsRow
+	if expired.HasReplies {
+		deletedDB, err = tombstoneChirp(ctx, q, expired.ID)
+	} else {
+		deletedDB, err = q.DeleteAttachments(ctx, expired.ID)
+		if err == nil {
+			err = q.DeleteChirp(ctx, expired.ID)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	for _, deleted := range deletedDB {
+		cfg.deleteBlobs(ctx, deleted.StorageKey, deleted.ThumbnailKey)
+	}
+
+	return nil
+}
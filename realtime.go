@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const audienceLoadTimeout = 5 * time.Second
+
+// audience is what a realtime subscriber mustn't be sent, mirroring the
+// filters on the REST feeds: chirps by users who blocked them, and chirps
+// by users they muted or with their muted keywords. Blocks and muted users
+// are loaded when they subscribe and reloaded whenever an
+// eventRelationsChanged names them. Muted keywords are matched by Postgres
+// when each chirp is published, as they are for the feeds, and carried on
+// the event.
+type audience struct {
+	userId    uuid.UUID
+	blockedBy map[uuid.UUID]struct{}
+	muted     map[uuid.UUID]struct{}
+}
+
+// loadAudience loads the audience for userId. Anonymous subscribers, with
+// userId uuid.Nil, get one that lets everything through.
+func (cfg *apiConfig) loadAudience(userId uuid.UUID) (*audience, error) {
+	a := &audience{
+		userId:    userId,
+		blockedBy: map[uuid.UUID]struct{}{},
+		muted:     map[uuid.UUID]struct{}{},
+	}
+
+	if userId == uuid.Nil {
+		return a, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), audienceLoadTimeout)
+	defer cancel()
+
+	blockerIds, err := cfg.db.GetBlockerIds(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blockerId := range blockerIds {
+		a.blockedBy[blockerId] = struct{}{}
+	}
+
+	mutedIds, err := cfg.db.GetMutedIds(ctx, userId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mutedId := range mutedIds {
+		a.muted[mutedId] = struct{}{}
+	}
+
+	return a, nil
+}
+
+// blocks reports whether e is about a chirp by, or rechirping or quoting, a
+// user who blocked the subscriber. These are left out everywhere.
+func (a *audience) blocks(e event) bool {
+	_, byBlocker := a.blockedBy[e.UserId]
+	_, refByBlocker := a.blockedBy[e.RefUserId]
+	return byBlocker || (e.RefUserId != uuid.Nil && refByBlocker)
+}
+
+// mutes reports whether e is about a chirp the subscriber muted, by its
+// author or its words. These are left out of feeds but not threads.
+func (a *audience) mutes(e event) bool {
+	if _, ok := a.muted[e.UserId]; ok {
+		return true
+	}
+
+	_, ok := e.KeywordMuterIds[a.userId]
+	return ok
+}
+
+// chirpCreatedEvent builds the event for chirpDB, which has just been
+// created or restored, along with its payload. The payload is shared by
+// every subscriber, so it's built as an anonymous viewer would see it,
+// without anyone's likes, bookmarks or votes.
+func (cfg *apiConfig) chirpCreatedEvent(ctx context.Context, chirpDB database.Chirp) (event, chirp, error) {
+	c, err := cfg.buildChirp(ctx, uuid.Nil, chirpDB)
+	if err != nil {
+		return event{}, chirp{}, err
+	}
+
+	muterIds, err := cfg.db.GetKeywordMuterIds(ctx, chirpDB.ID)
+	if err != nil {
+		return event{}, chirp{}, err
+	}
+
+	e := event{
+		Kind:            eventChirpCreated,
+		UserId:          c.UserId,
+		ChirpId:         c.Id,
+		KeywordMuterIds: make(map[uuid.UUID]struct{}, len(muterIds)),
+	}
+
+	for _, muterId := range muterIds {
+		e.KeywordMuterIds[muterId] = struct{}{}
+	}
+
+	if c.ParentId != nil {
+		e.ParentId = *c.ParentId
+	}
+
+	if c.RechirpOf != nil {
+		e.RefUserId = c.RechirpOf.UserId
+	}
+
+	if c.QuoteOf != nil {
+		e.RefUserId = c.QuoteOf.UserId
+	}
+
+	return e, c, nil
+}
+
+// publishChirpCreated pushes chirpDB to realtime clients.
+func (cfg *apiConfig) publishChirpCreated(ctx context.Context, chirpDB database.Chirp) {
+	// Through Postgres, only the chirp's ID is sent and every instance
+	// builds the event itself.
+	if cfg.broker.usePostgres {
+		cfg.broker.publish(event{
+			Kind:     eventChirpCreated,
+			UserId:   chirpDB.UserID,
+			ChirpId:  chirpDB.ID,
+			ParentId: chirpDB.ParentID.UUID,
+		}, nil)
+		return
+	}
+
+	e, c, err := cfg.chirpCreatedEvent(ctx, chirpDB)
+	if err != nil {
+		log.Printf("Error building %s event: %v", eventChirpCreated, err)
+		return
+	}
+
+	cfg.broker.publish(e, c)
+}
+
+// relationsChanged tells realtime subscribers for each of userIds to reload
+// their audience and timeline, e.g. after a block or follow.
+func (cfg *apiConfig) relationsChanged(userIds ...uuid.UUID) {
+	for _, userId := range userIds {
+		cfg.broker.publish(event{
+			Kind:   eventRelationsChanged,
+			UserId: userId,
+		}, nil)
+	}
+}
+
+// loadEvent rebuilds an event sent through Postgres NOTIFY, with the same
+// payload publish was given on the instance that sent it.
+func (cfg *apiConfig) loadEvent(ctx context.Context, m eventMessage) (event, interface{}, bool, error) {
+	switch m.Kind {
+	case eventChirpCreated:
+		chirpDB, err := cfg.db.GetChirpById(ctx, m.Id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return event{}, nil, false, nil
+		}
+		if err != nil {
+			return event{}, nil, false, err
+		}
+
+		if chirpDB.HiddenAt.Valid {
+			return event{}, nil, false, nil
+		}
+
+		e, c, err := cfg.chirpCreatedEvent(ctx, chirpDB)
+		if err != nil {
+			return event{}, nil, false, err
+		}
+
+		return e, c, true, nil
+	case eventChirpDeleted:
+		return event{
+			Kind:     m.Kind,
+			UserId:   m.UserId,
+			ChirpId:  m.Id,
+			ParentId: m.ParentId,
+		}, deletedChirp{Id: m.Id, UserId: m.UserId}, true, nil
+	case eventNotificationCreated:
+		notificationDB, err := cfg.db.GetNotificationById(ctx, m.Id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return event{}, nil, false, nil
+		}
+		if err != nil {
+			return event{}, nil, false, err
+		}
+
+		return event{
+			Kind:           m.Kind,
+			UserId:         notificationDB.UserID,
+			ChirpId:        notificationDB.ChirpID.UUID,
+			NotificationId: notificationDB.ID,
+		}, notificationFromDB(notificationDB, ""), true, nil
+	case eventRelationsChanged:
+		return event{Kind: m.Kind, UserId: m.UserId}, nil, true, nil
+	}
+
+	return event{}, nil, false, fmt.Errorf("unknown event kind %q", m.Kind)
+}
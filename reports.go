@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const maxReportDetailsLength = 500
+
+// reportReasons are the reasons a chirp or user can be reported for. They
+// match chk_reportreason in the schema.
+var reportReasons = map[string]struct{}{
+	"spam":          {},
+	"harassment":    {},
+	"hate":          {},
+	"violence":      {},
+	"sexual":        {},
+	"impersonation": {},
+	"other":         {},
+}
+
+const (
+	reportStatusOpen     = "open"
+	reportStatusResolved = "resolved"
+
+	reportResolutionActioned  = "actioned"
+	reportResolutionDismissed = "dismissed"
+)
+
+// Kinds of moderation action recorded in the audit trail.
+const (
+	moderationHideChirp     = "hide_chirp"
+	moderationUnhideChirp   = "unhide_chirp"
+	moderationSuspendUser   = "suspend_user"
+	moderationUnsuspendUser = "unsuspend_user"
+	moderationResolveReport = "resolve_report"
+	moderationSetRole       = "set_role"
+)
+
+// report is a user's complaint about a chirp or another user. Reports about
+// a chirp also name its author as UserId.
+type report struct {
+	Id         uuid.UUID  `json:"id"`
+	ReporterId uuid.UUID  `json:"reporter_id"`
+	UserId     uuid.UUID  `json:"user_id"`
+	ChirpId    *uuid.UUID `json:"chirp_id,omitempty"`
+	Reason     string     `json:"reason"`
+	Details    string     `json:"details"`
+	Status     string     `json:"status"`
+	Resolution string     `json:"resolution,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ResolvedAt *time.Time `json:"resolved_at,omitempty"`
+}
+
+func reportFromDB(reportDB database.Report) report {
+	rep := report{
+		Id:         reportDB.ID,
+		ReporterId: reportDB.ReporterID,
+		UserId:     reportDB.UserID,
+		Reason:     reportDB.Reason,
+		Details:    reportDB.Details,
+		Status:     reportDB.Status,
+		Resolution: reportDB.Resolution.String,
+		CreatedAt:  reportDB.CreatedAt,
+	}
+
+	if reportDB.ChirpID.Valid {
+		chirpId := reportDB.ChirpID.UUID
+		rep.ChirpId = &chirpId
+	}
+
+	if reportDB.ResolvedAt.Valid {
+		resolvedAt := reportDB.ResolvedAt.Time
+		rep.ResolvedAt = &resolvedAt
+	}
+
+	return rep
+}
+
+// moderationAction is an entry in the audit trail of everything moderators
+// have done.
+type moderationAction struct {
+	Id        uuid.UUID  `json:"id"`
+	ActorId   *uuid.UUID `json:"actor_id,omitempty"`
+	Action    string     `json:"action"`
+	ReportId  *uuid.UUID `json:"report_id,omitempty"`
+	ChirpId   *uuid.UUID `json:"chirp_id,omitempty"`
+	UserId    *uuid.UUID `json:"user_id,omitempty"`
+	Note      string     `json:"note"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+func moderationActionFromDB(actionDB database.ModerationAction) moderationAction {
+	action := moderationAction{
+		Id:        actionDB.ID,
+		Action:    actionDB.Action,
+		Note:      actionDB.Note,
+		CreatedAt: actionDB.CreatedAt,
+	}
+
+	if actionDB.ActorID.Valid {
+		actorId := actionDB.ActorID.UUID
+		action.ActorId = &actorId
+	}
+
+	if actionDB.ReportID.Valid {
+		reportId := actionDB.ReportID.UUID
+		action.ReportId = &reportId
+	}
+
+	if actionDB.ChirpID.Valid {
+		chirpId := actionDB.ChirpID.UUID
+		action.ChirpId = &chirpId
+	}
+
+	if actionDB.UserID.Valid {
+		userId := actionDB.UserID.UUID
+		action.UserId = &userId
+	}
+
+	return action
+}
+
+// moderate runs apply and records the action by actorId in the audit trail
+// in the same transaction, so nothing a moderator does goes unrecorded.
+func (cfg *apiConfig) moderate(ctx context.Context, actorId uuid.UUID, actionParams database.CreateModerationActionParams, apply func(q *database.Queries) error) error {
+	tx, err := cfg.dbConn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	q := cfg.db.WithTx(tx)
+
+	err = apply(q)
+	if err != nil {
+		return err
+	}
+
+	actionParams.ActorID = uuid.NullUUID{UUID: actorId, Valid: true}
+	_, err = q.CreateModerationAction(ctx, actionParams)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
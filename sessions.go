@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nurmuh-alhakim18/chirpy/internal/auth"
+	"github.com/nurmuh-alhakim18/chirpy/internal/database"
+)
+
+const refreshTokenTTL = 60 * 24 * time.Hour
+
+// createRefreshToken issues a refresh token in familyId. Signing in starts a
+// new family; refreshing adds the token it rotates to the same one.
+func createRefreshToken(ctx context.Context, q *database.Queries, userId, familyId uuid.UUID) (string, error) {
+	token, err := auth.MakeRefreshToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = q.CreateRefreshToken(ctx, database.CreateRefreshTokenParams{
+		Token:     token,
+		UserID:    userId,
+		FamilyID:  familyId,
+		ExpiresAt: time.Now().UTC().Add(refreshTokenTTL),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// revokeIfReused ends the session token belongs to if it has already been
+// rotated. Either it was stolen or the client is replaying it, and there's
+// no telling which, so whoever holds the session has to sign in again.
+func (cfg *apiConfig) revokeIfReused(ctx context.Context, token string) error {
+	tokenDB, err := cfg.db.GetRefreshToken(ctx, token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return err
+	}
+
+	if !tokenDB.RotatedAt.Valid {
+		return nil
+	}
+
+	log.Printf("Refresh token reused for user %s, revoking session %s", tokenDB.UserID, tokenDB.FamilyID)
+	return cfg.db.RevokeRefreshTokenFamily(ctx, tokenDB.FamilyID)
+}
@@ -0,0 +1,43 @@
+-- name: CreateAttachment :one
+INSERT INTO attachments (id, user_id, content_type, size_bytes, width, height, storage_key, thumbnail_key, created_at)
+VALUES (
+  $1, $2, $3, $4, $5, $6, $7, $8, NOW()
+)
+RETURNING *;
+
+-- name: CountAttachable :one
+SELECT COUNT(*)
+FROM attachments
+WHERE id = ANY(sqlc.arg('attachment_ids')::uuid[])
+  AND user_id = sqlc.arg('user_id')
+  AND chirp_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM users AS u WHERE u.avatar_id = attachments.id);
+
+-- name: AttachToChirp :execrows
+UPDATE attachments
+SET chirp_id = sqlc.arg('chirp_id')::uuid, position = array_position(sqlc.arg('attachment_ids')::uuid[], id)
+WHERE id = ANY(sqlc.arg('attachment_ids')::uuid[])
+  AND user_id = sqlc.arg('user_id')
+  AND chirp_id IS NULL
+  AND NOT EXISTS (SELECT 1 FROM users AS u WHERE u.avatar_id = attachments.id);
+
+-- name: GetAttachmentById :one
+SELECT *
+FROM attachments
+WHERE id = $1;
+
+-- name: GetAttachments :many
+SELECT *
+FROM attachments
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY chirp_id, position;
+
+-- name: DeleteAttachments :many
+DELETE FROM attachments
+WHERE chirp_id = sqlc.arg('chirp_id')::uuid
+RETURNING storage_key, thumbnail_key;
+
+-- name: DeleteAttachment :one
+DELETE FROM attachments
+WHERE id = $1
+RETURNING storage_key, thumbnail_key;
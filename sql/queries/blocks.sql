@@ -0,0 +1,67 @@
+-- name: CreateBlock :execrows
+INSERT INTO blocks (blocker_id, blocked_id, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBlock :exec
+DELETE FROM blocks
+WHERE blocker_id = $1 AND blocked_id = $2;
+
+-- name: IsBlockedBetween :one
+-- Reports whether either user has blocked the other.
+SELECT EXISTS (
+  SELECT 1
+  FROM blocks
+  WHERE (blocker_id = sqlc.arg('user_id') AND blocked_id = sqlc.arg('other_id'))
+    OR (blocker_id = sqlc.arg('other_id') AND blocked_id = sqlc.arg('user_id'))
+)::boolean AS blocked;
+
+-- name: GetBlockedUsers :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, b.created_at AS blocked_at
+FROM blocks AS b
+JOIN users AS u ON u.id = b.blocked_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE b.blocker_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('cursor_blocked_at')::timestamp IS NULL
+    OR (b.created_at, u.id) < (sqlc.narg('cursor_blocked_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY b.created_at DESC, u.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: CreateMute :exec
+INSERT INTO mutes (muter_id, muted_id, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMute :exec
+DELETE FROM mutes
+WHERE muter_id = $1 AND muted_id = $2;
+
+-- name: GetMutedUsers :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, m.created_at AS muted_at
+FROM mutes AS m
+JOIN users AS u ON u.id = m.muted_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE m.muter_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('cursor_muted_at')::timestamp IS NULL
+    OR (m.created_at, u.id) < (sqlc.narg('cursor_muted_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY m.created_at DESC, u.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetBlockerIds :many
+-- Users who have blocked this one, whose chirps it mustn't be sent.
+SELECT blocker_id
+FROM blocks
+WHERE blocked_id = $1;
+
+-- name: GetMutedIds :many
+SELECT muted_id
+FROM mutes
+WHERE muter_id = $1;
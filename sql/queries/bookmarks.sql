@@ -0,0 +1,37 @@
+-- name: CreateBookmark :exec
+INSERT INTO bookmarks (user_id, chirp_id, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteBookmark :exec
+DELETE FROM bookmarks
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetBookmarks :many
+SELECT sqlc.embed(c), b.created_at AS bookmarked_at
+FROM bookmarks AS b
+JOIN chirps AS c ON c.id = b.chirp_id
+WHERE b.user_id = sqlc.arg('user_id')
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('user_id'))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS bl
+    WHERE bl.blocked_id = sqlc.arg('user_id')
+      AND bl.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND (
+    sqlc.narg('cursor_bookmarked_at')::timestamp IS NULL
+    OR (b.created_at, c.id) < (sqlc.narg('cursor_bookmarked_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY b.created_at DESC, c.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetBookmarkedChirpIds :many
+SELECT chirp_id
+FROM bookmarks
+WHERE user_id = sqlc.arg('user_id')
+  AND chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[]);
@@ -0,0 +1,17 @@
+-- name: UpdateChirpBody :one
+WITH revision AS (
+  INSERT INTO chirp_revisions (id, chirp_id, body, created_at)
+  SELECT gen_random_uuid(), c.id, c.body, COALESCE(c.edited_at, c.created_at)
+  FROM chirps AS c
+  WHERE c.id = sqlc.arg('id')
+)
+UPDATE chirps
+SET body = sqlc.arg('body'), edited_at = NOW(), updated_at = NOW()
+WHERE chirps.id = sqlc.arg('id')
+RETURNING *;
+
+-- name: GetChirpRevisions :many
+SELECT *
+FROM chirp_revisions
+WHERE chirp_id = $1
+ORDER BY created_at DESC;
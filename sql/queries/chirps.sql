@@ -1,20 +1,218 @@
 -- name: CreateChirp :one
-INSERT INTO chirps (id, user_id, body, created_at, updated_at)
+INSERT INTO chirps (id, user_id, body, parent_id, rechirp_of_id, quote_of_id, created_at, updated_at)
 VALUES (
-  gen_random_uuid(), $1, $2, NOW(), NOW()
+  gen_random_uuid(), $1, $2, $3, $4, $5, NOW(), NOW()
 )
+ON CONFLICT (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL DO NOTHING
 RETURNING *;
 
--- name: GetAllChirps :many
-SELECT id, user_id, body, created_at, updated_at
-FROM chirps
-ORDER BY created_at;
+-- name: GetChirps :many
+-- Leaves out chirps by users who blocked or were muted by the viewer, which
+-- is uuid.Nil for anonymous requests, chirps with the viewer's muted keywords
+-- and chirps hidden by moderators, unless the viewer wrote them. An author's
+-- pinned chirps are left out of their chirps too, as they're shown first.
+SELECT c.*
+FROM chirps AS c
+WHERE NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id'))
+  AND (sqlc.narg('author_id')::uuid IS NULL OR c.user_id = sqlc.narg('author_id'))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM pinned_chirps AS p
+    WHERE p.user_id = sqlc.narg('author_id') AND p.chirp_id = c.id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('viewer_id')
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = sqlc.arg('viewer_id') AND m.muted_id = c.user_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = sqlc.arg('viewer_id')
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = COALESCE(c.rechirp_of_id, c.id))
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (c.created_at, c.id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY c.created_at, c.id
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsDesc :many
+-- Leaves out chirps by users who blocked or were muted by the viewer, which
+-- is uuid.Nil for anonymous requests, chirps with the viewer's muted keywords
+-- and chirps hidden by moderators, unless the viewer wrote them. An author's
+-- pinned chirps are left out of their chirps too, as they're shown first.
+SELECT c.*
+FROM chirps AS c
+WHERE NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id'))
+  AND (sqlc.narg('author_id')::uuid IS NULL OR c.user_id = sqlc.narg('author_id'))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM pinned_chirps AS p
+    WHERE p.user_id = sqlc.narg('author_id') AND p.chirp_id = c.id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('viewer_id')
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = sqlc.arg('viewer_id') AND m.muted_id = c.user_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = sqlc.arg('viewer_id')
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = COALESCE(c.rechirp_of_id, c.id))
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (c.created_at, c.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('limit');
 
 -- name: GetChirpById :one
-SELECT id, user_id, body, created_at, updated_at
+SELECT *
 FROM chirps
-WHERE id = $1;
+WHERE id = $1
+  AND deleted_at IS NULL;
+
+-- name: GetVisibleChirpById :one
+-- Like GetChirpById, but as if the chirp didn't exist when its author has
+-- blocked the viewer, or it was hidden by moderators and isn't the viewer's.
+SELECT c.*
+FROM chirps AS c
+WHERE c.id = sqlc.arg('chirp_id')
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id'))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('viewer_id')
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  );
+
+-- name: GetDeletedChirpById :one
+SELECT *
+FROM chirps
+WHERE id = $1
+  AND deleted_at IS NOT NULL;
 
 -- name: DeleteChirp :exec
 DELETE FROM chirps
-WHERE id = $1;
\ No newline at end of file
+WHERE id = $1;
+
+-- name: SoftDeleteChirp :exec
+-- Rechirps of the chirp go with it, marked with the same time so restoring
+-- brings back exactly those.
+UPDATE chirps
+SET deleted_at = NOW()
+WHERE (id = sqlc.arg('chirp_id') OR rechirp_of_id = sqlc.arg('chirp_id'))
+  AND deleted_at IS NULL;
+
+-- name: RestoreChirp :execrows
+UPDATE chirps
+SET deleted_at = NULL
+WHERE (chirps.id = sqlc.arg('chirp_id') OR chirps.rechirp_of_id = sqlc.arg('chirp_id'))
+  AND chirps.deleted_at = (SELECT c.deleted_at FROM chirps AS c WHERE c.id = sqlc.arg('chirp_id'))
+  AND chirps.deleted_at > NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8);
+
+-- name: GetExpiredDeletedChirps :many
+SELECT id, EXISTS (SELECT 1 FROM chirps AS r WHERE r.parent_id = c.id)::boolean AS has_replies
+FROM chirps AS c
+WHERE c.deleted_at <= NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8)
+  AND NOT c.is_tombstone
+ORDER BY c.deleted_at
+LIMIT sqlc.arg('limit');
+
+-- name: GetChirpsByIds :many
+-- Leaves out chirps whose author has blocked the viewer.
+SELECT c.*
+FROM chirps AS c
+WHERE c.id = ANY(sqlc.arg('chirp_ids')::uuid[])
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('viewer_id')
+      AND b.blocker_id = c.user_id
+  );
+
+-- name: TombstoneChirp :exec
+UPDATE chirps
+SET body = '', is_tombstone = true, updated_at = NOW()
+WHERE id = $1;
+
+-- name: GetReplyCounts :many
+SELECT parent_id::uuid AS chirp_id, COUNT(*) AS reply_count
+FROM chirps
+WHERE parent_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+  AND NOT is_tombstone
+  AND deleted_at IS NULL
+GROUP BY parent_id;
+
+-- name: GetChirpAncestors :many
+WITH RECURSIVE ancestors AS (
+  SELECT c.id, c.parent_id, 1 AS depth
+  FROM chirps AS c
+  WHERE c.id = (SELECT parent_id FROM chirps WHERE chirps.id = sqlc.arg('chirp_id'))
+  UNION ALL
+  SELECT c.id, c.parent_id, a.depth + 1
+  FROM chirps AS c
+  JOIN ancestors AS a ON c.id = a.parent_id
+)
+SELECT c.*
+FROM chirps AS c
+JOIN ancestors AS a ON a.id = c.id
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM blocks AS b
+  WHERE b.blocker_id = c.user_id AND b.blocked_id = sqlc.arg('viewer_id')
+)
+ORDER BY a.depth DESC;
+
+-- name: GetChirpDescendants :many
+-- Replies by users who blocked the viewer, and hidden replies that aren't the
+-- viewer's, are left out along with everything under them.
+WITH RECURSIVE descendants AS (
+  SELECT c.id
+  FROM chirps AS c
+  WHERE c.parent_id = sqlc.arg('chirp_id')::uuid
+    AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id')::uuid)
+    AND NOT EXISTS (
+      SELECT 1
+      FROM blocks AS b
+      WHERE b.blocker_id = c.user_id AND b.blocked_id = sqlc.arg('viewer_id')::uuid
+    )
+  UNION ALL
+  SELECT c.id
+  FROM chirps AS c
+  JOIN descendants AS d ON c.parent_id = d.id
+  WHERE (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id')::uuid)
+    AND NOT EXISTS (
+      SELECT 1
+      FROM blocks AS b
+      WHERE b.blocker_id = c.user_id AND b.blocked_id = sqlc.arg('viewer_id')::uuid
+    )
+)
+SELECT c.*
+FROM chirps AS c
+JOIN descendants AS d ON d.id = c.id
+ORDER BY c.created_at, c.id
+LIMIT sqlc.arg('limit');
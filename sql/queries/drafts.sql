@@ -0,0 +1,51 @@
+-- name: CreateDraft :one
+INSERT INTO drafts (id, user_id, body, parent_id, quote_of_id, attachment_ids, publish_at, created_at, updated_at)
+VALUES (
+  gen_random_uuid(), $1, $2, $3, $4, sqlc.arg('attachment_ids')::uuid[], $5, NOW(), NOW()
+)
+RETURNING *;
+
+-- name: GetDrafts :many
+SELECT *
+FROM drafts
+WHERE user_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetDraftById :one
+SELECT *
+FROM drafts
+WHERE id = $1;
+
+-- name: UpdateDraft :one
+UPDATE drafts
+SET body = $2, parent_id = $3, quote_of_id = $4, attachment_ids = sqlc.arg('attachment_ids')::uuid[], publish_at = $5, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteDraft :execrows
+DELETE FROM drafts
+WHERE id = $1;
+
+-- name: LockDraft :one
+SELECT *
+FROM drafts
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ClaimDueDraft :one
+SELECT *
+FROM drafts
+WHERE publish_at <= NOW()
+ORDER BY publish_at
+LIMIT 1
+FOR UPDATE SKIP LOCKED;
+
+-- name: UnscheduleDraft :exec
+UPDATE drafts
+SET publish_at = NULL, updated_at = NOW()
+WHERE id = $1;
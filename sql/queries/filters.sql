@@ -0,0 +1,78 @@
+-- name: GetFilterRules :many
+SELECT *
+FROM filter_rules
+ORDER BY phrase;
+
+-- name: CreateFilterRule :one
+INSERT INTO filter_rules (id, phrase, action, created_at, updated_at)
+VALUES (
+  gen_random_uuid(), $1, $2, NOW(), NOW()
+)
+RETURNING *;
+
+-- name: UpdateFilterRule :one
+UPDATE filter_rules
+SET phrase = $2, action = $3, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteFilterRule :execrows
+DELETE FROM filter_rules
+WHERE id = $1;
+
+-- name: UpsertChirpFlag :exec
+INSERT INTO chirp_flags (chirp_id, phrases, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT (chirp_id) DO UPDATE SET phrases = EXCLUDED.phrases;
+
+-- name: DeleteChirpFlag :execrows
+DELETE FROM chirp_flags
+WHERE chirp_id = $1;
+
+-- name: GetFlaggedChirps :many
+SELECT sqlc.embed(c), f.phrases, f.created_at AS flagged_at
+FROM chirp_flags AS f
+JOIN chirps AS c ON c.id = f.chirp_id
+WHERE c.deleted_at IS NULL
+  AND NOT c.is_tombstone
+  AND (
+    sqlc.narg('cursor_flagged_at')::timestamp IS NULL
+    OR (f.created_at, c.id) > (sqlc.narg('cursor_flagged_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY f.created_at, c.id
+LIMIT sqlc.arg('limit');
+
+-- name: GetMutedKeywords :many
+SELECT keyword, created_at
+FROM muted_keywords
+WHERE user_id = $1
+ORDER BY created_at, keyword;
+
+-- name: CountMutedKeywords :one
+SELECT COUNT(*)
+FROM muted_keywords
+WHERE user_id = $1;
+
+-- name: CreateMutedKeyword :execrows
+INSERT INTO muted_keywords (user_id, keyword, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMutedKeyword :exec
+DELETE FROM muted_keywords
+WHERE user_id = $1 AND keyword = $2;
+
+-- name: GetKeywordMuterIds :many
+-- Users with a muted keyword the chirp matches, or for a rechirp the
+-- original, matched the same way as on the feeds.
+SELECT DISTINCT k.user_id
+FROM muted_keywords AS k
+JOIN chirp_vectors AS v ON v.chirp_id = COALESCE(
+  (SELECT c.rechirp_of_id FROM chirps AS c WHERE c.id = sqlc.arg('chirp_id')::uuid),
+  sqlc.arg('chirp_id')::uuid
+)
+WHERE v.mute_vector @@ phraseto_tsquery('simple', k.keyword);
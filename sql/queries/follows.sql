@@ -0,0 +1,110 @@
+-- name: CreateFollow :execrows
+-- Nothing is inserted if either user has blocked the other.
+INSERT INTO follows (follower_id, followee_id, created_at)
+SELECT sqlc.arg('follower_id'), sqlc.arg('followee_id'), NOW()
+WHERE NOT EXISTS (
+  SELECT 1
+  FROM blocks AS b
+  WHERE (b.blocker_id = sqlc.arg('follower_id') AND b.blocked_id = sqlc.arg('followee_id'))
+    OR (b.blocker_id = sqlc.arg('followee_id') AND b.blocked_id = sqlc.arg('follower_id'))
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteFollowsBetween :exec
+DELETE FROM follows
+WHERE (follower_id = sqlc.arg('user_id') AND followee_id = sqlc.arg('other_id'))
+  OR (follower_id = sqlc.arg('other_id') AND followee_id = sqlc.arg('user_id'));
+
+-- name: DeleteFollow :exec
+DELETE FROM follows
+WHERE follower_id = $1 AND followee_id = $2;
+
+-- name: GetFollowers :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, f.created_at AS followed_at
+FROM follows AS f
+JOIN users AS u ON u.id = f.follower_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE f.followee_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('cursor_followed_at')::timestamp IS NULL
+    OR (f.created_at, u.id) < (sqlc.narg('cursor_followed_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY f.created_at DESC, u.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowing :many
+SELECT u.id, u.username, u.display_name, a.thumbnail_key AS avatar_key, u.is_chirpy_red, u.created_at, f.created_at AS followed_at
+FROM follows AS f
+JOIN users AS u ON u.id = f.followee_id
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE f.follower_id = sqlc.arg('user_id')
+  AND (
+    sqlc.narg('cursor_followed_at')::timestamp IS NULL
+    OR (f.created_at, u.id) < (sqlc.narg('cursor_followed_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY f.created_at DESC, u.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTimeline :many
+SELECT c.*
+FROM chirps AS c
+WHERE NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('user_id'))
+  AND (
+    c.user_id = sqlc.arg('user_id')
+    OR c.user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id'))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('user_id')
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = sqlc.arg('user_id') AND m.muted_id = c.user_id
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = sqlc.arg('user_id')
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = COALESCE(c.rechirp_of_id, c.id))
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  -- Show each original once, at the position of its newest rechirp.
+  AND NOT EXISTS (
+    SELECT 1
+    FROM chirps AS newer
+    WHERE COALESCE(newer.rechirp_of_id, newer.id) = COALESCE(c.rechirp_of_id, c.id)
+      AND (newer.created_at, newer.id) > (c.created_at, c.id)
+      AND NOT newer.is_tombstone
+      AND newer.deleted_at IS NULL
+      AND (
+        newer.user_id = sqlc.arg('user_id')
+        OR newer.user_id IN (SELECT followee_id FROM follows WHERE follower_id = sqlc.arg('user_id'))
+      )
+      AND NOT EXISTS (
+        SELECT 1
+        FROM mutes AS m
+        WHERE m.muter_id = sqlc.arg('user_id') AND m.muted_id = newer.user_id
+      )
+  )
+  AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (c.created_at, c.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetFollowingIds :many
+-- Muted users are left out, as they are from the timeline.
+SELECT f.followee_id
+FROM follows AS f
+WHERE f.follower_id = $1
+  AND NOT EXISTS (
+    SELECT 1
+    FROM mutes AS m
+    WHERE m.muter_id = f.follower_id AND m.muted_id = f.followee_id
+  );
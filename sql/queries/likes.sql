@@ -0,0 +1,19 @@
+-- name: CreateLike :execrows
+INSERT INTO likes (user_id, chirp_id, created_at)
+VALUES (
+  $1, $2, NOW()
+)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteLike :exec
+DELETE FROM likes
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetLikeStats :many
+SELECT
+  chirp_id,
+  COUNT(*) AS like_count,
+  bool_or(user_id = sqlc.arg('viewer_id'))::boolean AS liked_by_me
+FROM likes
+WHERE chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY chirp_id;
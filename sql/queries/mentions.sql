@@ -0,0 +1,18 @@
+-- name: CreateMentions :exec
+INSERT INTO mentions (chirp_id, user_id, start_index, end_index)
+SELECT
+  sqlc.arg('chirp_id'),
+  unnest(sqlc.arg('user_ids')::uuid[]),
+  unnest(sqlc.arg('start_indexes')::int[]),
+  unnest(sqlc.arg('end_indexes')::int[]);
+
+-- name: DeleteMentions :exec
+DELETE FROM mentions
+WHERE chirp_id = $1;
+
+-- name: GetMentions :many
+SELECT m.chirp_id, m.user_id, u.username::text AS username, m.start_index, m.end_index
+FROM mentions AS m
+JOIN users AS u ON u.id = m.user_id
+WHERE m.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+ORDER BY m.chirp_id, m.start_index;
@@ -0,0 +1,39 @@
+-- name: CreateNotifications :many
+INSERT INTO notifications (id, user_id, actor_id, kind, chirp_id, created_at, read_at)
+SELECT gen_random_uuid(), unnest(sqlc.arg('user_ids')::uuid[]), sqlc.arg('actor_id'), sqlc.arg('kind'), sqlc.narg('chirp_id'), NOW(), NULL
+RETURNING *;
+
+-- name: GetNotifications :many
+SELECT sqlc.embed(n), u.username AS actor_username
+FROM notifications AS n
+JOIN users AS u ON u.id = n.actor_id
+WHERE n.user_id = sqlc.arg('user_id')
+  AND (NOT sqlc.arg('unread_only')::boolean OR n.read_at IS NULL)
+  AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (n.created_at, n.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY n.created_at DESC, n.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetUnreadNotificationCount :one
+SELECT COUNT(*)
+FROM notifications
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: MarkNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = sqlc.arg('user_id')
+  AND read_at IS NULL
+  AND id = ANY(sqlc.arg('ids')::uuid[]);
+
+-- name: MarkAllNotificationsRead :exec
+UPDATE notifications
+SET read_at = NOW()
+WHERE user_id = $1 AND read_at IS NULL;
+
+-- name: GetNotificationById :one
+SELECT *
+FROM notifications
+WHERE id = $1;
@@ -0,0 +1,29 @@
+-- name: PinChirp :execrows
+-- Takes the first free slot. No rows are inserted if the chirp is already
+-- pinned or every slot is taken.
+INSERT INTO pinned_chirps (user_id, slot, chirp_id, created_at)
+SELECT sqlc.arg('user_id'), MIN(s.slot), sqlc.arg('chirp_id'), NOW()
+FROM generate_series(0, 2) AS s(slot)
+WHERE s.slot NOT IN (SELECT p.slot FROM pinned_chirps AS p WHERE p.user_id = sqlc.arg('user_id'))
+HAVING MIN(s.slot) IS NOT NULL
+ON CONFLICT (user_id, chirp_id) DO NOTHING;
+
+-- name: UnpinChirp :exec
+DELETE FROM pinned_chirps
+WHERE user_id = $1 AND chirp_id = $2;
+
+-- name: GetPinnedChirps :many
+SELECT c.*
+FROM pinned_chirps AS p
+JOIN chirps AS c ON c.id = p.chirp_id
+WHERE p.user_id = sqlc.arg('user_id')
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id'))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('viewer_id')
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+ORDER BY p.created_at DESC;
@@ -0,0 +1,41 @@
+-- name: CreatePoll :exec
+WITH poll AS (
+  INSERT INTO polls (chirp_id, expires_at, created_at)
+  VALUES (sqlc.arg('chirp_id'), sqlc.arg('expires_at'), NOW())
+  RETURNING chirp_id
+)
+INSERT INTO poll_options (chirp_id, position, text)
+SELECT
+  (SELECT chirp_id FROM poll),
+  unnest(sqlc.arg('positions')::int[]),
+  unnest(sqlc.arg('options')::text[]);
+
+-- name: GetPolls :many
+SELECT p.chirp_id, p.expires_at, p.expires_at <= NOW() AS closed, v.position AS voted_position
+FROM polls AS p
+JOIN chirps AS c ON c.id = p.chirp_id
+LEFT JOIN poll_votes AS v ON v.chirp_id = p.chirp_id AND v.user_id = sqlc.arg('viewer_id')
+WHERE p.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+  AND c.deleted_at IS NULL;
+
+-- name: GetPollOptions :many
+SELECT o.chirp_id, o.position, o.text, COUNT(v.user_id) AS vote_count
+FROM poll_options AS o
+LEFT JOIN poll_votes AS v ON v.chirp_id = o.chirp_id AND v.position = o.position
+WHERE o.chirp_id = ANY(sqlc.arg('chirp_ids')::uuid[])
+GROUP BY o.chirp_id, o.position
+ORDER BY o.chirp_id, o.position;
+
+-- name: CreatePollVote :execrows
+INSERT INTO poll_votes (chirp_id, user_id, position, created_at)
+SELECT p.chirp_id, sqlc.arg('user_id'), sqlc.arg('position'), NOW()
+FROM polls AS p
+JOIN chirps AS c ON c.id = p.chirp_id
+WHERE p.chirp_id = sqlc.arg('chirp_id')
+  AND p.expires_at > NOW()
+  AND c.deleted_at IS NULL
+ON CONFLICT (chirp_id, user_id) DO NOTHING;
+
+-- name: DeletePoll :exec
+DELETE FROM polls
+WHERE chirp_id = $1;
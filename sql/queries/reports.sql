@@ -0,0 +1,60 @@
+-- name: CreateReport :one
+INSERT INTO reports (id, reporter_id, user_id, chirp_id, reason, details, status, created_at)
+VALUES (
+  gen_random_uuid(), $1, $2, $3, $4, $5, 'open', NOW()
+)
+RETURNING *;
+
+-- name: GetReportById :one
+SELECT *
+FROM reports
+WHERE id = $1;
+
+-- name: GetReports :many
+SELECT *
+FROM reports
+WHERE status = sqlc.arg('status')
+  AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (created_at, id) > (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY created_at, id
+LIMIT sqlc.arg('limit');
+
+-- name: ResolveReport :one
+UPDATE reports
+SET status = 'resolved', resolution = $2, resolved_at = NOW()
+WHERE id = $1
+  AND status = 'open'
+RETURNING *;
+
+-- name: CreateModerationAction :one
+INSERT INTO moderation_actions (id, actor_id, action, report_id, chirp_id, user_id, note, created_at)
+VALUES (
+  gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW()
+)
+RETURNING *;
+
+-- name: GetModerationActions :many
+SELECT *
+FROM moderation_actions
+WHERE sqlc.narg('cursor_created_at')::timestamp IS NULL
+  OR (created_at, id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: SetChirpHidden :execrows
+UPDATE chirps
+SET hidden_at = CASE WHEN sqlc.arg('hidden')::boolean THEN NOW() END
+WHERE id = sqlc.arg('chirp_id');
+
+-- name: SetUserSuspended :execrows
+UPDATE users
+SET suspended_at = CASE WHEN sqlc.arg('suspended')::boolean THEN NOW() END, updated_at = NOW()
+WHERE id = sqlc.arg('user_id');
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE refresh_tokens
+SET revoked_at = NOW(), updated_at = NOW()
+WHERE user_id = $1
+  AND revoked_at IS NULL;
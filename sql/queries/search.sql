@@ -0,0 +1,34 @@
+-- name: SearchChirps :many
+-- Leaves out chirps by users who blocked the viewer, chirps with the viewer's
+-- muted keywords and chirps hidden by moderators, unless the viewer wrote
+-- them.
+SELECT
+  sqlc.embed(c),
+  ts_headline(
+    'english', c.body, query,
+    'StartSel=' || chr(1) || ', StopSel=' || chr(2) || ', MaxFragments=2, MaxWords=20, MinWords=5'
+  )::text AS snippet,
+  ts_rank(v.search_vector, query)::real AS rank
+FROM chirps AS c
+JOIN chirp_vectors AS v ON v.chirp_id = c.id
+CROSS JOIN websearch_to_tsquery('english', sqlc.arg('query')) AS query
+WHERE v.search_vector @@ query
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id'))
+  AND (sqlc.narg('author_id')::uuid IS NULL OR c.user_id = sqlc.narg('author_id'))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('viewer_id')
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = sqlc.arg('viewer_id')
+      AND v.mute_vector @@ phraseto_tsquery('simple', k.keyword)
+  )
+ORDER BY rank DESC, c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('limit')
+OFFSET sqlc.arg('offset');
@@ -0,0 +1,63 @@
+-- name: CreateChirpTags :exec
+-- Tags are dated with the chirp rather than when they're saved, so editing an
+-- old chirp doesn't count towards what's trending now.
+INSERT INTO chirp_tags (chirp_id, tag, created_at)
+SELECT c.id, unnest(sqlc.arg('tags')::text[]), c.created_at
+FROM chirps AS c
+WHERE c.id = sqlc.arg('chirp_id')
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteChirpTags :exec
+DELETE FROM chirp_tags
+WHERE chirp_id = $1;
+
+-- name: GetChirpsByTag :many
+-- Leaves out chirps by users who blocked the viewer, chirps with the viewer's
+-- muted keywords and chirps hidden by moderators, unless the viewer wrote
+-- them.
+SELECT c.*
+FROM chirps AS c
+JOIN chirp_tags AS t ON t.chirp_id = c.id
+WHERE t.tag = sqlc.arg('tag')
+  AND NOT c.is_tombstone
+  AND c.deleted_at IS NULL
+  AND (c.hidden_at IS NULL OR c.user_id = sqlc.arg('viewer_id'))
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocked_id = sqlc.arg('viewer_id')
+      AND b.blocker_id IN (c.user_id, (SELECT o.user_id FROM chirps AS o WHERE o.id = c.rechirp_of_id))
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM muted_keywords AS k
+    WHERE k.user_id = sqlc.arg('viewer_id')
+      AND (SELECT v.mute_vector FROM chirp_vectors AS v WHERE v.chirp_id = c.id)
+        @@ phraseto_tsquery('simple', k.keyword)
+  )
+  AND (
+    sqlc.narg('cursor_created_at')::timestamp IS NULL
+    OR (c.created_at, c.id) < (sqlc.narg('cursor_created_at')::timestamp, sqlc.narg('cursor_id')::uuid)
+  )
+ORDER BY c.created_at DESC, c.id DESC
+LIMIT sqlc.arg('limit');
+
+-- name: GetTrendingTags :many
+-- Compares each tag's uses in the latest window with the window before it,
+-- so tags that are picking up speed rank above ones that are merely common.
+WITH counts AS (
+  SELECT
+    t.tag,
+    COUNT(*) FILTER (WHERE t.created_at >= NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8)) AS recent_count,
+    COUNT(*) FILTER (WHERE t.created_at < NOW() - make_interval(secs => sqlc.arg('window_seconds')::float8)) AS previous_count
+  FROM chirp_tags AS t
+  JOIN chirps AS c ON c.id = t.chirp_id
+  WHERE t.created_at >= NOW() - 2 * make_interval(secs => sqlc.arg('window_seconds')::float8)
+    AND c.deleted_at IS NULL
+  GROUP BY t.tag
+)
+SELECT tag, recent_count, previous_count
+FROM counts
+WHERE recent_count > 0
+ORDER BY recent_count - previous_count DESC, recent_count DESC, tag
+LIMIT sqlc.arg('limit');
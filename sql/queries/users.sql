@@ -1,7 +1,7 @@
 -- name: CreateUser :one
-INSERT INTO users (id, email, created_at, updated_at, hashed_password)
+INSERT INTO users (id, email, created_at, updated_at, hashed_password, username)
 VALUES (
-  gen_random_uuid(), $1, NOW(), NOW(), $2
+  gen_random_uuid(), $1, NOW(), NOW(), $2, $3
 )
 RETURNING *;
 
@@ -15,34 +15,117 @@ SELECT *
 FROM users
 WHERE id = $1;
 
+-- name: GetUsersByUsernames :many
+-- Users who have blocked author_id are left out, so they can't be mentioned
+-- by them.
+SELECT u.id, u.username::text AS username
+FROM users AS u
+WHERE LOWER(u.username) = ANY(sqlc.arg('usernames')::text[])
+  AND NOT EXISTS (
+    SELECT 1
+    FROM blocks AS b
+    WHERE b.blocker_id = u.id AND b.blocked_id = sqlc.arg('author_id')
+  );
+
 -- name: CreateRefreshToken :one
-INSERT INTO refresh_tokens (token, user_id, created_at, updated_at, expires_at, revoked_at)
+INSERT INTO refresh_tokens (token, user_id, family_id, created_at, updated_at, expires_at, revoked_at)
 VALUES (
-  $1, $2, NOW(), NOW(), $3, NULL
+  $1, $2, $3, NOW(), NOW(), $4, NULL
 )
 RETURNING *;
 
 -- name: UpdateUser :one
 UPDATE users
-SET email = $1, hashed_password = $2, updated_at = NOW()
-WHERE id = $3
+SET
+  email = sqlc.arg('email'),
+  hashed_password = sqlc.arg('hashed_password'),
+  username = COALESCE(sqlc.narg('username'), username),
+  updated_at = NOW()
+WHERE id = sqlc.arg('id')
+RETURNING *;
+
+-- name: UpdateUserProfile :one
+UPDATE users
+SET
+  username = $2,
+  display_name = $3,
+  bio = $4,
+  avatar_id = $5,
+  updated_at = NOW()
+WHERE id = $1
 RETURNING *;
 
--- name: RevokeRefreshToken :one
+-- name: GetUserProfile :one
+SELECT
+  sqlc.embed(u),
+  a.thumbnail_key AS avatar_key,
+  (SELECT COUNT(*) FROM follows AS f WHERE f.followee_id = u.id) AS follower_count,
+  (SELECT COUNT(*) FROM follows AS f WHERE f.follower_id = u.id) AS following_count,
+  (
+    SELECT COUNT(*)
+    FROM chirps AS c
+    WHERE c.user_id = u.id
+      AND c.rechirp_of_id IS NULL
+      AND NOT c.is_tombstone
+      AND c.deleted_at IS NULL
+  ) AS chirp_count
+FROM users AS u
+LEFT JOIN attachments AS a ON a.id = u.avatar_id
+WHERE LOWER(u.username) = LOWER(sqlc.arg('username'));
+
+-- name: RevokeRefreshToken :exec
+-- Signs out of the session the token belongs to, including any tokens it
+-- was rotated into.
 UPDATE refresh_tokens
 SET revoked_at = NOW(), updated_at = NOW()
+WHERE family_id = (SELECT r.family_id FROM refresh_tokens AS r WHERE r.token = $1)
+  AND revoked_at IS NULL;
+
+-- name: GetRefreshToken :one
+SELECT *
+FROM refresh_tokens
+WHERE token = $1;
+
+-- name: RotateRefreshToken :one
+-- Marks a live token as used. Only one caller can rotate a given token, so
+-- a second attempt finds nothing and is treated as reuse.
+UPDATE refresh_tokens
+SET rotated_at = NOW(), updated_at = NOW()
 WHERE token = $1
+  AND rotated_at IS NULL
+  AND revoked_at IS NULL
+  AND expires_at > NOW()
 RETURNING *;
 
--- name: GetUserFromRefreshToken :one
-SELECT u.*
-FROM users AS u
-JOIN refresh_tokens AS r ON u.id = r.user_id
-WHERE r.token = $1
-  AND r.revoked_at IS NULL
-  AND r.expires_at > NOW();
+-- name: RevokeRefreshTokenFamily :exec
+-- Ends a session whose rotated token was presented again, flagging it so
+-- the reuse is on record.
+UPDATE refresh_tokens
+SET
+  revoked_at = COALESCE(revoked_at, NOW()),
+  reuse_detected_at = NOW(),
+  updated_at = NOW()
+WHERE family_id = $1;
 
 -- name: UpgradeChirpyRed :exec
 UPDATE users
 SET is_chirpy_red = true
 WHERE id = $1;
+
+-- name: SetUserRole :execrows
+UPDATE users
+SET role = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: BootstrapAdmin :execrows
+-- Promotes the user with this email, but only while there are no admins, so
+-- it can't be used to take over an installation that's already set up.
+UPDATE users
+SET role = 'admin', updated_at = NOW()
+WHERE users.email = $1
+  AND NOT EXISTS (SELECT 1 FROM users AS a WHERE a.role = 'admin');
+
+-- name: CountAdmins :one
+SELECT COUNT(*)
+FROM users
+WHERE role = 'admin';
@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_chirps_created_at_id ON chirps (created_at, id);
+CREATE INDEX idx_chirps_user_id_created_at_id ON chirps (user_id, created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_chirps_user_id_created_at_id;
+DROP INDEX idx_chirps_created_at_id;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE follows (
+  follower_id UUID NOT NULL,
+  followee_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (follower_id, followee_id),
+  CONSTRAINT fk_followfollower FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_followfollowee FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT chk_follownotself CHECK (follower_id <> followee_id)
+);
+
+CREATE INDEX idx_follows_followee_id ON follows (followee_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE follows;
+-- +goose StatementEnd
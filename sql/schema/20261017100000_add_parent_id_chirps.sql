@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN parent_id UUID,
+ADD COLUMN is_tombstone BOOLEAN NOT NULL DEFAULT false,
+ADD CONSTRAINT fk_chirpparent FOREIGN KEY (parent_id) REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_chirps_parent_id ON chirps (parent_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps
+DROP COLUMN is_tombstone,
+DROP COLUMN parent_id;
+-- +goose StatementEnd
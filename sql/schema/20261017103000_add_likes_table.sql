@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE likes (
+  user_id UUID NOT NULL,
+  chirp_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (user_id, chirp_id),
+  CONSTRAINT fk_likeuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_likechirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_likes_chirp_id ON likes (chirp_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE likes;
+-- +goose StatementEnd
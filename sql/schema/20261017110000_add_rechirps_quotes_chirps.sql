@@ -0,0 +1,18 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN rechirp_of_id UUID,
+ADD COLUMN quote_of_id UUID,
+ADD CONSTRAINT fk_chirprechirp FOREIGN KEY (rechirp_of_id) REFERENCES chirps(id) ON DELETE CASCADE,
+ADD CONSTRAINT fk_chirpquote FOREIGN KEY (quote_of_id) REFERENCES chirps(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX idx_chirps_user_id_rechirp_of_id ON chirps (user_id, rechirp_of_id) WHERE rechirp_of_id IS NOT NULL;
+CREATE INDEX idx_chirps_original_id ON chirps ((COALESCE(rechirp_of_id, id)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps
+DROP COLUMN quote_of_id,
+DROP COLUMN rechirp_of_id;
+-- +goose StatementEnd
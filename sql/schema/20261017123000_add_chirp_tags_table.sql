@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE chirp_tags (
+  chirp_id UUID NOT NULL,
+  tag TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (chirp_id, tag),
+  CONSTRAINT fk_chirptagchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_chirp_tags_tag_created_at ON chirp_tags (tag, created_at);
+CREATE INDEX idx_chirp_tags_created_at ON chirp_tags (created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE chirp_tags;
+-- +goose StatementEnd
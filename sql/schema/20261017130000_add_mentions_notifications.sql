@@ -0,0 +1,43 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN username TEXT;
+
+CREATE UNIQUE INDEX idx_users_username ON users (LOWER(username));
+
+CREATE TABLE mentions (
+  chirp_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  start_index INTEGER NOT NULL,
+  end_index INTEGER NOT NULL,
+
+  PRIMARY KEY (chirp_id, start_index),
+  CONSTRAINT fk_mentionchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+  CONSTRAINT fk_mentionuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE notifications (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL,
+  actor_id UUID NOT NULL,
+  kind TEXT NOT NULL,
+  chirp_id UUID,
+  created_at TIMESTAMP NOT NULL,
+  read_at TIMESTAMP,
+
+  CONSTRAINT fk_notificationuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_notificationactor FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_notificationchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_notifications_user_id_created_at ON notifications (user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE notifications;
+DROP TABLE mentions;
+
+ALTER TABLE users
+DROP COLUMN username;
+-- +goose StatementEnd
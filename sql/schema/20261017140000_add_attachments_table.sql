@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE attachments (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL,
+  chirp_id UUID,
+  position INTEGER NOT NULL DEFAULT 0,
+  content_type TEXT NOT NULL,
+  size_bytes INTEGER NOT NULL,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  storage_key TEXT NOT NULL,
+  thumbnail_key TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  CONSTRAINT fk_attachmentuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_attachmentchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_attachments_chirp_id_position ON attachments (chirp_id, position);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE attachments;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE polls (
+  chirp_id UUID PRIMARY KEY,
+  expires_at TIMESTAMP NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  CONSTRAINT fk_pollchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE TABLE poll_options (
+  chirp_id UUID NOT NULL,
+  position INTEGER NOT NULL,
+  text TEXT NOT NULL,
+
+  PRIMARY KEY (chirp_id, position),
+  CONSTRAINT fk_polloptionpoll FOREIGN KEY (chirp_id) REFERENCES polls(chirp_id) ON DELETE CASCADE
+);
+
+-- The primary key is what limits each user to one vote per poll.
+CREATE TABLE poll_votes (
+  chirp_id UUID NOT NULL,
+  user_id UUID NOT NULL,
+  position INTEGER NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (chirp_id, user_id),
+  CONSTRAINT fk_pollvoteoption FOREIGN KEY (chirp_id, position) REFERENCES poll_options(chirp_id, position) ON DELETE CASCADE,
+  CONSTRAINT fk_pollvoteuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE poll_votes;
+DROP TABLE poll_options;
+DROP TABLE polls;
+-- +goose StatementEnd
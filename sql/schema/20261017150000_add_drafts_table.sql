@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE drafts (
+  id UUID PRIMARY KEY,
+  user_id UUID NOT NULL,
+  body TEXT NOT NULL,
+  parent_id UUID,
+  quote_of_id UUID,
+  attachment_ids UUID[] NOT NULL DEFAULT '{}',
+  publish_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+
+  CONSTRAINT fk_draftuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_drafts_user_id_created_at ON drafts (user_id, created_at);
+CREATE INDEX idx_drafts_publish_at ON drafts (publish_at) WHERE publish_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE drafts;
+-- +goose StatementEnd
@@ -0,0 +1,33 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE bookmarks (
+  user_id UUID NOT NULL,
+  chirp_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (user_id, chirp_id),
+  CONSTRAINT fk_bookmarkuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_bookmarkchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_bookmarks_user_id_created_at ON bookmarks (user_id, created_at);
+
+-- Each user has three pin slots, so the limit holds even when pins race.
+CREATE TABLE pinned_chirps (
+  user_id UUID NOT NULL,
+  slot INTEGER NOT NULL CHECK (slot BETWEEN 0 AND 2),
+  chirp_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (user_id, slot),
+  UNIQUE (user_id, chirp_id),
+  CONSTRAINT fk_pinnedchirpuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_pinnedchirpchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE pinned_chirps;
+DROP TABLE bookmarks;
+-- +goose StatementEnd
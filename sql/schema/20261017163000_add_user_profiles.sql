@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN display_name TEXT NOT NULL DEFAULT '',
+ADD COLUMN bio TEXT NOT NULL DEFAULT '',
+ADD COLUMN avatar_id UUID,
+ADD CONSTRAINT fk_useravatar FOREIGN KEY (avatar_id) REFERENCES attachments(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE users
+DROP COLUMN avatar_id,
+DROP COLUMN bio,
+DROP COLUMN display_name;
+-- +goose StatementEnd
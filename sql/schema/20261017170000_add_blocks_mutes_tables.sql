@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE blocks (
+  blocker_id UUID NOT NULL,
+  blocked_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (blocker_id, blocked_id),
+  CONSTRAINT fk_blockblocker FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_blockblocked FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT chk_blocknotself CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX idx_blocks_blocked_id ON blocks (blocked_id);
+
+CREATE TABLE mutes (
+  muter_id UUID NOT NULL,
+  muted_id UUID NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (muter_id, muted_id),
+  CONSTRAINT fk_mutemuter FOREIGN KEY (muter_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_mutemuted FOREIGN KEY (muted_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT chk_mutenotself CHECK (muter_id <> muted_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE mutes;
+DROP TABLE blocks;
+-- +goose StatementEnd
@@ -0,0 +1,45 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE filter_rules (
+  id UUID PRIMARY KEY,
+  phrase TEXT NOT NULL UNIQUE,
+  action TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+  updated_at TIMESTAMP NOT NULL,
+
+  CONSTRAINT chk_filterruleaction CHECK (action IN ('mask', 'reject', 'flag'))
+);
+
+-- The words that used to be hard-coded.
+INSERT INTO filter_rules (id, phrase, action, created_at, updated_at)
+VALUES
+  (gen_random_uuid(), 'kerfuffle', 'mask', NOW(), NOW()),
+  (gen_random_uuid(), 'sharbert', 'mask', NOW(), NOW()),
+  (gen_random_uuid(), 'fornax', 'mask', NOW(), NOW());
+
+CREATE TABLE chirp_flags (
+  chirp_id UUID PRIMARY KEY,
+  phrases TEXT[] NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  CONSTRAINT fk_chirpflagchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+CREATE INDEX idx_chirp_flags_created_at ON chirp_flags (created_at, chirp_id);
+
+CREATE TABLE muted_keywords (
+  user_id UUID NOT NULL,
+  keyword TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL,
+
+  PRIMARY KEY (user_id, keyword),
+  CONSTRAINT fk_mutedkeyworduser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE muted_keywords;
+DROP TABLE chirp_flags;
+DROP TABLE filter_rules;
+-- +goose StatementEnd
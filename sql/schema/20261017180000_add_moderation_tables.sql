@@ -0,0 +1,68 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN hidden_at TIMESTAMP;
+
+ALTER TABLE users
+ADD COLUMN suspended_at TIMESTAMP;
+
+CREATE TABLE reports (
+  id UUID PRIMARY KEY,
+  reporter_id UUID NOT NULL,
+  -- The reported user, who wrote chirp_id if it's set.
+  user_id UUID NOT NULL,
+  chirp_id UUID,
+  reason TEXT NOT NULL,
+  details TEXT NOT NULL DEFAULT '',
+  status TEXT NOT NULL DEFAULT 'open',
+  resolution TEXT,
+  created_at TIMESTAMP NOT NULL,
+  resolved_at TIMESTAMP,
+
+  CONSTRAINT fk_reportreporter FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_reportuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  CONSTRAINT fk_reportchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE,
+  CONSTRAINT chk_reportreason CHECK (reason IN ('spam', 'harassment', 'hate', 'violence', 'sexual', 'impersonation', 'other')),
+  CONSTRAINT chk_reportstatus CHECK (status IN ('open', 'resolved')),
+  CONSTRAINT chk_reportresolution CHECK ((status = 'open') = (resolution IS NULL))
+);
+
+-- One open report per reporter for each chirp, and for each user outside of
+-- their chirps.
+CREATE UNIQUE INDEX idx_reports_open_chirp ON reports (reporter_id, chirp_id)
+WHERE status = 'open' AND chirp_id IS NOT NULL;
+
+CREATE UNIQUE INDEX idx_reports_open_user ON reports (reporter_id, user_id)
+WHERE status = 'open' AND chirp_id IS NULL;
+
+CREATE INDEX idx_reports_status_created_at ON reports (status, created_at, id);
+
+CREATE TABLE moderation_actions (
+  id UUID PRIMARY KEY,
+  action TEXT NOT NULL,
+  report_id UUID,
+  chirp_id UUID,
+  user_id UUID,
+  note TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL,
+
+  -- The audit trail outlives what it's about.
+  CONSTRAINT fk_moderationactionreport FOREIGN KEY (report_id) REFERENCES reports(id) ON DELETE SET NULL,
+  CONSTRAINT fk_moderationactionchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE SET NULL,
+  CONSTRAINT fk_moderationactionuser FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX idx_moderation_actions_created_at ON moderation_actions (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE moderation_actions;
+DROP TABLE reports;
+
+ALTER TABLE users
+DROP COLUMN suspended_at;
+
+ALTER TABLE chirps
+DROP COLUMN hidden_at;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users
+ADD COLUMN role TEXT NOT NULL DEFAULT 'user',
+ADD CONSTRAINT chk_userrole CHECK (role IN ('user', 'moderator', 'admin'));
+
+-- Who did it, now that moderators sign in as themselves.
+ALTER TABLE moderation_actions
+ADD COLUMN actor_id UUID,
+ADD CONSTRAINT fk_moderationactionactor FOREIGN KEY (actor_id) REFERENCES users(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE moderation_actions
+DROP COLUMN actor_id;
+
+ALTER TABLE users
+DROP COLUMN role;
+-- +goose StatementEnd
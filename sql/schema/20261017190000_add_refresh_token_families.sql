@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Each sign-in starts a family of refresh tokens. Refreshing rotates the
+-- token, and presenting one that was already rotated revokes the family.
+ALTER TABLE refresh_tokens
+ADD COLUMN family_id UUID,
+ADD COLUMN rotated_at TIMESTAMP,
+ADD COLUMN reuse_detected_at TIMESTAMP;
+
+UPDATE refresh_tokens SET family_id = gen_random_uuid();
+
+ALTER TABLE refresh_tokens
+ALTER COLUMN family_id SET NOT NULL;
+
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens
+DROP COLUMN reuse_detected_at,
+DROP COLUMN rotated_at,
+DROP COLUMN family_id;
+-- +goose StatementEnd
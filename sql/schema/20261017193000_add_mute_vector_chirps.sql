@@ -0,0 +1,16 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Muted keywords are matched word for word, without the stemming the search
+-- vector uses, so they get a vector of their own rather than one computed
+-- for every chirp read.
+ALTER TABLE chirps
+ADD COLUMN mute_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', body)) STORED;
+
+CREATE INDEX idx_chirps_mute_vector ON chirps USING GIN (mute_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps
+DROP COLUMN mute_vector;
+-- +goose StatementEnd
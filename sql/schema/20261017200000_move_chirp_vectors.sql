@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+-- The text search vectors live beside chirps rather than in them, so reading
+-- a chirp doesn't pull them along. A trigger keeps them in step with the body.
+CREATE TABLE chirp_vectors (
+  chirp_id UUID PRIMARY KEY,
+  search_vector TSVECTOR NOT NULL,
+  mute_vector TSVECTOR NOT NULL,
+
+  CONSTRAINT fk_chirpvectorchirp FOREIGN KEY (chirp_id) REFERENCES chirps(id) ON DELETE CASCADE
+);
+
+INSERT INTO chirp_vectors (chirp_id, search_vector, mute_vector)
+SELECT id, search_vector, mute_vector
+FROM chirps;
+
+CREATE INDEX idx_chirp_vectors_search_vector ON chirp_vectors USING GIN (search_vector);
+CREATE INDEX idx_chirp_vectors_mute_vector ON chirp_vectors USING GIN (mute_vector);
+
+CREATE FUNCTION save_chirp_vectors() RETURNS TRIGGER AS $$
+BEGIN
+  INSERT INTO chirp_vectors (chirp_id, search_vector, mute_vector)
+  VALUES (NEW.id, to_tsvector('english', NEW.body), to_tsvector('simple', NEW.body))
+  ON CONFLICT (chirp_id) DO UPDATE
+  SET search_vector = EXCLUDED.search_vector, mute_vector = EXCLUDED.mute_vector;
+
+  RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_chirps_save_vectors
+AFTER INSERT OR UPDATE OF body ON chirps
+FOR EACH ROW EXECUTE FUNCTION save_chirp_vectors();
+
+ALTER TABLE chirps
+DROP COLUMN search_vector,
+DROP COLUMN mute_vector;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE chirps
+ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', body)) STORED,
+ADD COLUMN mute_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('simple', body)) STORED;
+
+CREATE INDEX idx_chirps_search_vector ON chirps USING GIN (search_vector);
+CREATE INDEX idx_chirps_mute_vector ON chirps USING GIN (mute_vector);
+
+DROP TRIGGER trg_chirps_save_vectors ON chirps;
+DROP FUNCTION save_chirp_vectors;
+DROP TABLE chirp_vectors;
+-- +goose StatementEnd